@@ -0,0 +1,64 @@
+package checkpoint
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Read returns the keys of the shards in the checkpoint file, one per line,
+// or none when the file doesn't exist yet.
+func Read(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return map[string]bool{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	done := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			done[line] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Error reading checkpoint %s: %v", path, err)
+	}
+	return done, nil
+}
+
+// Write replaces the checkpoint file with the keys of the shards done. The
+// keys are written to a temporary file first, so a crash never leaves a
+// partial checkpoint.
+func Write(path string, done map[string]bool) error {
+	var keys []string
+	for k := range done {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\n", k)
+	}
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
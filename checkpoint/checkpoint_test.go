@@ -0,0 +1,100 @@
+package checkpoint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint")
+
+	done, err := Read(path)
+	if err != nil || len(done) != 0 {
+		t.Fatalf("Read of a missing checkpoint = %v, %v, want none", done, err)
+	}
+
+	for _, want := range []map[string]bool{
+		{"db/rp/2": true, "db/rp/1": true, "other/rp/10": true},
+		{"db/rp/1": true},
+		{},
+	} {
+		if err := Write(path, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Read(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Read = %v, want %v", got, want)
+		}
+	}
+
+	// only the checkpoint is left, without temporary files
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("%d files left, want the checkpoint only", len(files))
+	}
+}
+
+func TestWriteSorted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint")
+
+	if err := Write(path, map[string]bool{"b/rp/1": true, "a/rp/2": true, "a/rp/1": true}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a/rp/1\na/rp/2\nb/rp/1\n"; string(b) != want {
+		t.Errorf("checkpoint %q, want %q", b, want)
+	}
+}
+
+func TestReadBlankLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint")
+
+	if err := ioutil.WriteFile(path, []byte("\n db/rp/1 \n\ndb/rp/2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	done, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{"db/rp/1": true, "db/rp/2": true}; !reflect.DeepEqual(done, want) {
+		t.Errorf("Read = %v, want %v", done, want)
+	}
+}
+
+func TestWriteMissingDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := Write(filepath.Join(dir, "missing", "checkpoint"), map[string]bool{"db/rp/1": true}); err == nil {
+		t.Errorf("no error writing to a missing directory")
+	}
+}
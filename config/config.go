@@ -0,0 +1,173 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// EnvPrefix starts the name of the environment variables setting flags, like
+// INFLUXDB_MIGRATE_WRITEURL for -writeurl or INFLUXDB_MIGRATE_METRICS_ADDR
+// for -metrics-addr.
+const EnvPrefix = "INFLUXDB_MIGRATE_"
+
+// Config is a migration configuration file in TOML. Its top level keys are
+// named like the flags and set them:
+//
+//	writeurl = "http://newhost:8086/"
+//	pointspersecond = 20000
+//	betweenwrites = "10ms"
+//	map = ["metrics=metrics_legacy", "old_*=merged"]
+//
+// An array sets a flag that can be given many times once per value. The
+// rules of those flags can also be written as sections, in the order they
+// are applied:
+//
+//	[map]
+//	metrics = "metrics_legacy"
+//	"old_*" = "merged"
+//
+//	[field]
+//	"*" = "drop debug_*"
+//	"web/requests" = ["keep value,count", "coerce value float"]
+//
+// where map, rename and sanitize hold old = "new" or reason = "action" pairs
+// and tag, field and convert hold the rules of each scope.
+type Config struct {
+	Flags map[string][]string
+}
+
+// sections are the flags that can be set by a section, with what joins each
+// key of the section to its values.
+var sections = map[string]string{
+	"map":      "=",
+	"rename":   "=",
+	"sanitize": "=",
+	"tag":      ": ",
+	"field":    ": ",
+	"convert":  ": ",
+}
+
+// Load reads the configuration file. Keys that are neither flags of fs nor
+// sections of them are an error, so typos don't go unnoticed.
+func Load(path string, fs *flag.FlagSet) (*Config, error) {
+	var top map[string]interface{}
+	md, err := toml.DecodeFile(path, &top)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading config %s: %v", path, err)
+	}
+
+	c := &Config{Flags: make(map[string][]string)}
+	var keys []string
+	for k := range top {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == "config" || fs.Lookup(k) == nil {
+			return nil, fmt.Errorf("Error reading config %s: unknown option %s", path, k)
+		}
+		if _, ok := top[k].(map[string]interface{}); ok {
+			continue
+		}
+		values, err := scalars(top[k])
+		if err != nil {
+			return nil, fmt.Errorf("Error reading config %s: option %s %v", path, k, err)
+		}
+		c.Flags[k] = values
+	}
+
+	// the keys of the sections are read in the order of the file, as the
+	// first rule matching is the one used
+	for _, key := range md.Keys() {
+		if len(key) == 1 {
+			if _, ok := top[key[0]].(map[string]interface{}); ok && sections[key[0]] == "" {
+				return nil, fmt.Errorf("Error reading config %s: %s can't be a section", path, key[0])
+			}
+			continue
+		}
+		if len(key) != 2 {
+			return nil, fmt.Errorf("Error reading config %s: unknown option %s", path, key)
+		}
+		section := top[key[0]].(map[string]interface{})
+		values, err := scalars(section[key[1]])
+		if err != nil {
+			return nil, fmt.Errorf("Error reading config %s: %s %v", path, key, err)
+		}
+		for _, v := range values {
+			c.Flags[key[0]] = append(c.Flags[key[0]], key[1]+sections[key[0]]+v)
+		}
+	}
+	return c, nil
+}
+
+// scalars returns a value, or the values of an array, as given in a flag.
+func scalars(value interface{}) ([]string, error) {
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	var ret []string
+	for _, v := range values {
+		switch v.(type) {
+		case string, int64, float64, bool:
+			ret = append(ret, fmt.Sprint(v))
+		default:
+			return nil, fmt.Errorf("must be a string, number, boolean or an array of them")
+		}
+	}
+	return ret, nil
+}
+
+// Apply sets the flags of fs from the file, except the ones in set, which
+// were given in a way that overrides the file. The flags it sets are added
+// to set.
+func (c *Config) Apply(fs *flag.FlagSet, set map[string]bool) error {
+	for name, values := range c.Flags {
+		if set[name] {
+			continue
+		}
+		for _, value := range values {
+			if err := fs.Set(name, value); err != nil {
+				return fmt.Errorf("Invalid %s %s in config: %v", name, value, err)
+			}
+		}
+		set[name] = true
+	}
+	return nil
+}
+
+// ApplyEnv sets the flags of fs from the environment, except the ones in set.
+// The flags it sets are added to set.
+func ApplyEnv(fs *flag.FlagSet, set map[string]bool) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || set[f.Name] {
+			return
+		}
+		name := EnvPrefix + strings.ToUpper(strings.Replace(f.Name, "-", "_", -1))
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+		if serr := fs.Set(f.Name, value); serr != nil {
+			err = fmt.Errorf("Invalid %s %s: %v", name, value, serr)
+			return
+		}
+		set[f.Name] = true
+	})
+	return err
+}
+
+// Set returns the flags given in the command line.
+func Set(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
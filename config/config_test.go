@@ -0,0 +1,162 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// list is a flag that can be given many times.
+type list []string
+
+func (l *list) String() string     { return strings.Join(*l, ",") }
+func (l *list) Set(v string) error { *l = append(*l, v); return nil }
+
+type flags struct {
+	fs          *flag.FlagSet
+	writeurl    *string
+	points      *int
+	metricsaddr *string
+	maps        list
+	fields      list
+}
+
+func newflags() *flags {
+	f := &flags{fs: flag.NewFlagSet("test", flag.ContinueOnError)}
+	f.writeurl = f.fs.String("writeurl", "http://localhost:8086/", "")
+	f.points = f.fs.Int("pointsperwrite", 10, "")
+	f.metricsaddr = f.fs.String("metrics-addr", "", "")
+	f.fs.Var(&f.maps, "map", "")
+	f.fs.Var(&f.fields, "field", "")
+	f.fs.Var(&list{}, "rename", "")
+	f.fs.String("config", "", "")
+	return f
+}
+
+// load writes the configuration to a file and loads it.
+func load(t *testing.T, content string, fs *flag.FlagSet) (*Config, error) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.toml")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path, fs)
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string][]string
+		err     string
+	}{
+		{
+			name:    "top level",
+			content: "writeurl = \"http://new:8086/\"\npointsperwrite = 500\nmap = [\"a=b\", \"c=d\"]\n",
+			want:    map[string][]string{"writeurl": {"http://new:8086/"}, "pointsperwrite": {"500"}, "map": {"a=b", "c=d"}},
+		},
+		{
+			name:    "sections in file order",
+			content: "[map]\nz = \"1\"\n\"old_*\" = \"merged\"\na = \"2\"\n\n[field]\n\"*\" = \"drop debug_*\"\n\"web/requests\" = [\"keep value\", \"coerce value float\"]\n",
+			want: map[string][]string{
+				"map":   {"z=1", "old_*=merged", "a=2"},
+				"field": {"*: drop debug_*", "web/requests: keep value", "web/requests: coerce value float"},
+			},
+		},
+		{
+			name:    "array and section",
+			content: "map = [\"a=b\"]\n[rename]\ncpu = \"cpu_total\"\n",
+			want:    map[string][]string{"map": {"a=b"}, "rename": {"cpu=cpu_total"}},
+		},
+		{name: "unknown option", content: "wirteurl = \"x\"\n", err: "unknown option wirteurl"},
+		{name: "config in config", content: "config = \"other.toml\"\n", err: "unknown option config"},
+		{name: "not a section", content: "[writeurl]\nx = \"y\"\n", err: "writeurl can't be a section"},
+		{name: "nested section", content: "[field.web]\nx = \"y\"\n", err: "field.web must be a string"},
+		{name: "table value", content: "map = [[\"a\"]]\n", err: "must be a string"},
+		{name: "invalid toml", content: "writeurl = \n", err: "Error reading config"},
+	}
+	for _, tt := range tests {
+		c, err := load(t, tt.content, newflags().fs)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error = %v, want %s", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(c.Flags, tt.want) {
+			t.Errorf("%s: flags = %v, want %v", tt.name, c.Flags, tt.want)
+		}
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	f := newflags()
+	if err := f.fs.Parse([]string{"-writeurl", "http://flag:8086/"}); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(EnvPrefix+"WRITEURL", "http://env:8086/")
+	os.Setenv(EnvPrefix+"POINTSPERWRITE", "20")
+	os.Setenv(EnvPrefix+"METRICS_ADDR", ":9999")
+	defer os.Unsetenv(EnvPrefix + "WRITEURL")
+	defer os.Unsetenv(EnvPrefix + "POINTSPERWRITE")
+	defer os.Unsetenv(EnvPrefix + "METRICS_ADDR")
+
+	set := Set(f.fs)
+	if err := ApplyEnv(f.fs, set); err != nil {
+		t.Fatal(err)
+	}
+	c, err := load(t, "writeurl = \"http://file:8086/\"\npointsperwrite = 30\nmap = [\"a=b\"]\n", f.fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Apply(f.fs, set); err != nil {
+		t.Fatal(err)
+	}
+
+	if *f.writeurl != "http://flag:8086/" {
+		t.Errorf("writeurl = %s, want the one of the flag", *f.writeurl)
+	}
+	if *f.points != 20 {
+		t.Errorf("pointsperwrite = %d, want the 20 of the environment", *f.points)
+	}
+	if *f.metricsaddr != ":9999" {
+		t.Errorf("metrics-addr = %s, want the :9999 of the environment", *f.metricsaddr)
+	}
+	if want := (list{"a=b"}); !reflect.DeepEqual(f.maps, want) {
+		t.Errorf("map = %v, want %v of the file", f.maps, want)
+	}
+	for _, name := range []string{"writeurl", "pointsperwrite", "metrics-addr", "map"} {
+		if !set[name] {
+			t.Errorf("%s not marked as set", name)
+		}
+	}
+	if set["field"] {
+		t.Errorf("field marked as set")
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	f := newflags()
+	c := &Config{Flags: map[string][]string{"pointsperwrite": {"many"}}}
+	if err := c.Apply(f.fs, map[string]bool{}); err == nil {
+		t.Errorf("no error applying an invalid pointsperwrite")
+	}
+
+	os.Setenv(EnvPrefix+"POINTSPERWRITE", "many")
+	defer os.Unsetenv(EnvPrefix + "POINTSPERWRITE")
+	if err := ApplyEnv(newflags().fs, map[string]bool{}); err == nil || !strings.Contains(err.Error(), EnvPrefix+"POINTSPERWRITE") {
+		t.Errorf("error = %v, want one naming %sPOINTSPERWRITE", err, EnvPrefix)
+	}
+}
@@ -0,0 +1,146 @@
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/vladlopes/influxdb-migrate/progress"
+	"github.com/vladlopes/influxdb-migrate/ratelimit"
+	"github.com/vladlopes/influxdb-migrate/writer"
+)
+
+// Handler lets an operator look at and steer a running migration:
+//
+//	GET  /status     the progress, rates and whether it is paused, as JSON
+//	POST /pause      writers wait before their next write
+//	POST /resume     writers continue
+//	PUT  /rate       pointspersecond and bytespersecond, 0 for no limit
+//	PUT  /batchsize  pointsperwrite
+//	POST /stop       reading stops; the migration ends with a checkpoint
+//	                 once the batches already read are written
+//
+// Values are given as form or query parameters.
+type Handler struct {
+	Writer *writer.Writer
+	// Limiter is nil when the writes aren't limited, like when exporting.
+	// The rates are then reported as 0 and can't be changed.
+	Limiter  *ratelimit.Limiter
+	Snapshot func() progress.Snapshot
+	// Stop ends the migration, closing Stopped.
+	Stop    func()
+	Stopped <-chan struct{}
+
+	once sync.Once
+	mux  *http.ServeMux
+}
+
+// Status is the reply of GET /status.
+type Status struct {
+	Paused          bool              `json:"paused"`
+	Stopping        bool              `json:"stopping"`
+	PointsPerSecond float64           `json:"pointspersecond"`
+	BytesPerSecond  float64           `json:"bytespersecond"`
+	PointsPerWrite  int               `json:"pointsperwrite"`
+	Progress        progress.Snapshot `json:"progress"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.once.Do(func() {
+		h.mux = http.NewServeMux()
+		h.mux.HandleFunc("/status", h.method("GET", h.status))
+		h.mux.HandleFunc("/pause", h.method("POST", func(w http.ResponseWriter, r *http.Request) {
+			h.Writer.Pause()
+			h.status(w, r)
+		}))
+		h.mux.HandleFunc("/resume", h.method("POST", func(w http.ResponseWriter, r *http.Request) {
+			h.Writer.Resume()
+			h.status(w, r)
+		}))
+		h.mux.HandleFunc("/rate", h.method("PUT", h.rate))
+		h.mux.HandleFunc("/batchsize", h.method("PUT", h.batchsize))
+		h.mux.HandleFunc("/stop", h.method("POST", func(w http.ResponseWriter, r *http.Request) {
+			h.Stop()
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			h.status(w, r)
+		}))
+	})
+	h.mux.ServeHTTP(w, r)
+}
+
+// method only lets requests with the method m reach fn.
+func (h *Handler) method(m string, fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != m {
+			w.Header().Set("Allow", m)
+			http.Error(w, fmt.Sprintf("Method %s not allowed, use %s", r.Method, m), http.StatusMethodNotAllowed)
+			return
+		}
+		fn(w, r)
+	}
+}
+
+func (h *Handler) status(w http.ResponseWriter, r *http.Request) {
+	s := Status{
+		Paused:         h.Writer.Paused(),
+		Stopping:       h.stopping(),
+		PointsPerWrite: h.Writer.CurrentPointsPerWrite(),
+		Progress:       h.Snapshot(),
+	}
+	if h.Limiter != nil {
+		s.PointsPerSecond, s.BytesPerSecond = h.Limiter.Rates()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+func (h *Handler) stopping() bool {
+	select {
+	case <-h.Stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+func (h *Handler) rate(w http.ResponseWriter, r *http.Request) {
+	if h.Limiter == nil {
+		http.Error(w, "The writes of this command aren't rate limited", http.StatusConflict)
+		return
+	}
+	pps, bps := h.Limiter.Rates()
+	for _, v := range []struct {
+		name string
+		rate *float64
+	}{
+		{"pointspersecond", &pps},
+		{"bytespersecond", &bps},
+	} {
+		s := r.FormValue(v.name)
+		if s == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f < 0 {
+			http.Error(w, fmt.Sprintf("Invalid %s %s", v.name, s), http.StatusBadRequest)
+			return
+		}
+		*v.rate = f
+	}
+	h.Limiter.SetRates(pps, bps)
+	h.status(w, r)
+}
+
+func (h *Handler) batchsize(w http.ResponseWriter, r *http.Request) {
+	s := r.FormValue("pointsperwrite")
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		http.Error(w, fmt.Sprintf("Invalid pointsperwrite %s. Must be at least 1", s), http.StatusBadRequest)
+		return
+	}
+	h.Writer.SetPointsPerWrite(n)
+	h.status(w, r)
+}
@@ -0,0 +1,141 @@
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladlopes/influxdb-migrate/progress"
+	"github.com/vladlopes/influxdb-migrate/ratelimit"
+	"github.com/vladlopes/influxdb-migrate/writer"
+)
+
+func newhandler(limiter *ratelimit.Limiter) *Handler {
+	stopped := make(chan struct{})
+	return &Handler{
+		Writer:   &writer.Writer{PointsPerWrite: 100},
+		Limiter:  limiter,
+		Snapshot: func() progress.Snapshot { return progress.Snapshot{Time: time.Now(), PointsRead: 7, ETA: -1} },
+		Stop:     func() { close(stopped) },
+		Stopped:  stopped,
+	}
+}
+
+// do sends a request to h, returning the status code and, when it is OK or
+// Accepted, the status replied.
+func do(t *testing.T, h *Handler, method, path string, values url.Values) (int, Status) {
+	req := httptest.NewRequest(method, path, strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var s Status
+	if rec.Code == http.StatusOK || rec.Code == http.StatusAccepted {
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s %s: content type %q, want application/json", method, path, ct)
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&s); err != nil {
+			t.Errorf("%s %s: %v", method, path, err)
+		}
+	}
+	return rec.Code, s
+}
+
+func TestStatus(t *testing.T) {
+	h := newhandler(ratelimit.New(10, 20))
+	code, s := do(t, h, "GET", "/status", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /status = %d", code)
+	}
+	if s.Paused || s.Stopping || s.PointsPerSecond != 10 || s.BytesPerSecond != 20 || s.PointsPerWrite != 100 || s.Progress.PointsRead != 7 {
+		t.Errorf("GET /status = %+v", s)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	h := newhandler(nil)
+	if _, s := do(t, h, "POST", "/pause", nil); !s.Paused || !h.Writer.Paused() {
+		t.Errorf("not paused after POST /pause")
+	}
+	if _, s := do(t, h, "POST", "/resume", nil); s.Paused || h.Writer.Paused() {
+		t.Errorf("still paused after POST /resume")
+	}
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name   string
+		values url.Values
+		code   int
+		points float64
+		bytes  float64
+	}{
+		{name: "both", values: url.Values{"pointspersecond": {"5"}, "bytespersecond": {"6"}}, code: http.StatusOK, points: 5, bytes: 6},
+		{name: "points only", values: url.Values{"pointspersecond": {"0"}}, code: http.StatusOK, points: 0, bytes: 20},
+		{name: "invalid", values: url.Values{"pointspersecond": {"fast"}}, code: http.StatusBadRequest, points: 10, bytes: 20},
+		{name: "negative", values: url.Values{"bytespersecond": {"-1"}}, code: http.StatusBadRequest, points: 10, bytes: 20},
+	}
+	for _, tt := range tests {
+		h := newhandler(ratelimit.New(10, 20))
+		if code, _ := do(t, h, "PUT", "/rate", tt.values); code != tt.code {
+			t.Errorf("%s: PUT /rate = %d, want %d", tt.name, code, tt.code)
+		}
+		if p, b := h.Limiter.Rates(); p != tt.points || b != tt.bytes {
+			t.Errorf("%s: rates %v, %v, want %v, %v", tt.name, p, b, tt.points, tt.bytes)
+		}
+	}
+
+	h := newhandler(nil)
+	if code, _ := do(t, h, "PUT", "/rate", url.Values{"pointspersecond": {"5"}}); code != http.StatusConflict {
+		t.Errorf("PUT /rate without a limiter = %d, want %d", code, http.StatusConflict)
+	}
+}
+
+func TestBatchSize(t *testing.T) {
+	h := newhandler(nil)
+	if code, s := do(t, h, "PUT", "/batchsize", url.Values{"pointsperwrite": {"50"}}); code != http.StatusOK || s.PointsPerWrite != 50 {
+		t.Errorf("PUT /batchsize = %d, %d points per write, want 200, 50", code, s.PointsPerWrite)
+	}
+	for _, v := range []string{"", "0", "many"} {
+		if code, _ := do(t, h, "PUT", "/batchsize", url.Values{"pointsperwrite": {v}}); code != http.StatusBadRequest {
+			t.Errorf("PUT /batchsize %q = %d, want %d", v, code, http.StatusBadRequest)
+		}
+	}
+	if n := h.Writer.CurrentPointsPerWrite(); n != 50 {
+		t.Errorf("%d points per write after invalid sizes, want 50", n)
+	}
+}
+
+func TestStop(t *testing.T) {
+	h := newhandler(nil)
+	code, s := do(t, h, "POST", "/stop", nil)
+	if code != http.StatusAccepted || !s.Stopping {
+		t.Errorf("POST /stop = %d, stopping %v, want 202, true", code, s.Stopping)
+	}
+}
+
+func TestMethod(t *testing.T) {
+	for path, method := range map[string]string{
+		"/status":    "GET",
+		"/pause":     "POST",
+		"/resume":    "POST",
+		"/rate":      "PUT",
+		"/batchsize": "PUT",
+		"/stop":      "POST",
+	} {
+		h := newhandler(nil)
+		wrong := "DELETE"
+		req := httptest.NewRequest(wrong, path, nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != method {
+			t.Errorf("%s %s = %d, Allow %q, want %d, %s", wrong, path, rec.Code, rec.Header().Get("Allow"), http.StatusMethodNotAllowed, method)
+		}
+		if h.stopping() {
+			t.Errorf("%s %s stopped the migration", wrong, path)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"sync"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// Budget limits the estimated bytes of points decoded by the readers but not
+// yet written. A nil Budget has no limit.
+type Budget struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	max  int64
+	used int64
+}
+
+func NewBudget(max int64) *Budget {
+	b := &Budget{max: max}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Acquire blocks until n bytes fit in the budget. A single request bigger
+// than the whole budget is let through once nothing else is in use, so a
+// huge chunk slows the pipeline down instead of stopping it.
+func (b *Budget) Acquire(n int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	for b.used > 0 && b.used+int64(n) > b.max {
+		b.cond.Wait()
+	}
+	b.used += int64(n)
+	b.mu.Unlock()
+}
+
+// Release gives back n bytes acquired before.
+func (b *Budget) Release(n int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.used -= int64(n)
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// Used returns the bytes in use and the maximum of the budget.
+func (b *Budget) Used() (int64, int64) {
+	if b == nil {
+		return 0, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used, b.max
+}
+
+// BatchSize returns the estimated bytes of the points of a batch, the same
+// amount acquired by the Chunker that sent it.
+func BatchSize(bp client.BatchPoints) int {
+	size := 0
+	for _, p := range bp.Points {
+		size += PointSize(p)
+	}
+	return size
+}
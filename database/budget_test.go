@@ -0,0 +1,75 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+// acquired tells whether Acquire(n) returns before the timeout.
+func acquired(b *Budget, n int) bool {
+	done := make(chan struct{})
+	go func() {
+		b.Acquire(n)
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(50 * time.Millisecond):
+		return false
+	}
+}
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(100)
+	if !acquired(b, 60) {
+		t.Fatalf("Acquire(60) of an empty budget of 100 blocked")
+	}
+	if !acquired(b, 40) {
+		t.Fatalf("Acquire(40) with 60 of 100 used blocked")
+	}
+	if used, max := b.Used(); used != 100 || max != 100 {
+		t.Errorf("Used = %d, %d, want 100, 100", used, max)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.Acquire(10)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("Acquire(10) of a full budget didn't block")
+	case <-time.After(50 * time.Millisecond):
+	}
+	b.Release(60)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Acquire(10) still blocked after a Release(60)")
+	}
+	if used, _ := b.Used(); used != 50 {
+		t.Errorf("Used = %d, want 50", used)
+	}
+}
+
+func TestBudgetBiggerThanMax(t *testing.T) {
+	b := NewBudget(100)
+	if !acquired(b, 500) {
+		t.Fatalf("Acquire(500) of an empty budget of 100 blocked")
+	}
+	if acquired(b, 1) {
+		t.Fatalf("Acquire(1) with 500 of 100 used didn't block")
+	}
+}
+
+func TestNilBudget(t *testing.T) {
+	var b *Budget
+	if !acquired(b, 1<<30) {
+		t.Fatalf("Acquire of a nil budget blocked")
+	}
+	b.Release(1 << 30)
+	if used, max := b.Used(); used != 0 || max != 0 {
+		t.Errorf("Used of a nil budget = %d, %d, want 0, 0", used, max)
+	}
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// Options control how the readers emit the points they decode.
+type Options struct {
+	// MaxPoints is the maximum number of points sent in one batch.
+	MaxPoints int
+	// MaxBytes is the maximum estimated size of the points sent in one batch.
+	// Zero means no limit.
+	MaxBytes int
+	// Workers is the number of shards read at the same time. When Ordered
+	// it is the number of retention policies read at the same time instead.
+	Workers int
+	// Ordered merges the shards of each retention policy so their points
+	// are sent in time order.
+	Ordered bool
+	// Budget is acquired before each batch is sent. The writer of the batch
+	// must release it.
+	Budget *Budget
+	// Progress, when set, is told about every batch sent and every shard
+	// finished.
+	Progress Progress
+	// Done has the keys of the shards already migrated, which aren't read
+	// again.
+	Done map[string]bool
+	// Context, once canceled, stops the readers: no other shard is started
+	// and the shards being read are left unfinished.
+	Context context.Context
+	// Transform, when set, changes the points before they are sent.
+	Transform Transform
+	// Precision is the one of the batches sent, empty for nanoseconds. The
+	// transform must truncate the timestamps to it.
+	Precision string
+}
+
+// Transform changes what is read from the old version before it is written.
+type Transform interface {
+	// Map returns the database and retention policy the points of db and
+	// rp are written to.
+	Map(db, rp string) (string, string)
+	// Point changes a point of db and rp, the ones of the old version,
+	// returning false to drop it.
+	Point(db, rp string, p *client.Point) bool
+}
+
+// stop returns the channel closed when the readers must stop, nil when they
+// never do.
+func (o Options) stop() <-chan struct{} {
+	if o.Context == nil {
+		return nil
+	}
+	return o.Context.Done()
+}
+
+// Chunker groups the points of a retention policy in batches bounded by the
+// options, sending each batch as soon as it is full. This keeps the memory
+// used by a reader flat no matter how many points a series has.
+type Chunker struct {
+	opts   Options
+	db     string
+	rp     string
+	bp     client.BatchPoints
+	size   int
+	points chan<- client.BatchPoints
+}
+
+// NewChunker returns a chunker for the points of a retention policy of the
+// old version, sent to the one the transform maps it to.
+func NewChunker(dbname, rpname string, opts Options, points chan<- client.BatchPoints) *Chunker {
+	todb, torp := dbname, rpname
+	if opts.Transform != nil {
+		todb, torp = opts.Transform.Map(dbname, rpname)
+	}
+	return &Chunker{
+		opts: opts,
+		db:   dbname,
+		rp:   rpname,
+		bp: client.BatchPoints{
+			Database:        todb,
+			RetentionPolicy: torp,
+			Precision:       opts.Precision,
+		},
+		points: points,
+	}
+}
+
+// Add transforms the point and appends it to the current batch, sending it
+// first if the point wouldn't fit. The size is estimated after transforming,
+// the same size the writer releases from the budget.
+func (c *Chunker) Add(p client.Point) {
+	if c.opts.Transform != nil && !c.opts.Transform.Point(c.db, c.rp, &p) {
+		return
+	}
+	size := PointSize(p)
+	if len(c.bp.Points) > 0 &&
+		((c.opts.MaxPoints > 0 && len(c.bp.Points) >= c.opts.MaxPoints) ||
+			(c.opts.MaxBytes > 0 && c.size+size > c.opts.MaxBytes)) {
+		c.Flush()
+	}
+	c.bp.Points = append(c.bp.Points, p)
+	c.size += size
+}
+
+// Flush sends the current batch if it has any point. The batch is dropped
+// when the readers are stopped while waiting to send it.
+func (c *Chunker) Flush() {
+	if len(c.bp.Points) == 0 {
+		return
+	}
+	bp := c.bp
+	c.opts.Budget.Acquire(c.size)
+	select {
+	case c.points <- bp:
+	case <-c.opts.stop():
+		c.opts.Budget.Release(c.size)
+		c.bp.Points = nil
+		c.size = 0
+		return
+	}
+	if c.opts.Progress != nil {
+		c.opts.Progress.Read(bp.Database, bp.RetentionPolicy, len(bp.Points))
+	}
+	c.bp.Points = nil
+	c.size = 0
+}
+
+// PointSize estimates the bytes used by a point, roughly its size in line
+// protocol.
+func PointSize(p client.Point) int {
+	size := len(p.Measurement) + 8
+	for k, v := range p.Tags {
+		size += len(k) + len(v) + 2
+	}
+	for k, v := range p.Fields {
+		size += len(k) + 1
+		if s, ok := v.(string); ok {
+			size += len(s) + 2
+		} else {
+			size += 8
+		}
+	}
+	return size
+}
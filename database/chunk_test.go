@@ -0,0 +1,88 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+func point(name string) client.Point {
+	return client.Point{Measurement: name, Fields: map[string]interface{}{"value": "x"}}
+}
+
+func TestChunker(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxpoints int
+		maxbytes  int
+		points    int
+		sizes     []int
+	}{
+		{name: "by points", maxpoints: 3, points: 7, sizes: []int{3, 3, 1}},
+		{name: "exact", maxpoints: 2, points: 4, sizes: []int{2, 2}},
+		{name: "by bytes", maxpoints: 100, maxbytes: 2 * PointSize(point("cpu")), points: 5, sizes: []int{2, 2, 1}},
+		{name: "point bigger than the bytes", maxpoints: 100, maxbytes: 1, points: 2, sizes: []int{1, 1}},
+		{name: "no points", maxpoints: 3, points: 0, sizes: nil},
+	}
+	for _, tt := range tests {
+		c := make(chan client.BatchPoints, 10)
+		ch := NewChunker("db", "rp", Options{MaxPoints: tt.maxpoints, MaxBytes: tt.maxbytes}, c)
+		for i := 0; i < tt.points; i++ {
+			ch.Add(point("cpu"))
+		}
+		ch.Flush()
+		close(c)
+		var sizes []int
+		for bp := range c {
+			if bp.Database != "db" || bp.RetentionPolicy != "rp" {
+				t.Errorf("%s: batch of %s/%s, want db/rp", tt.name, bp.Database, bp.RetentionPolicy)
+			}
+			sizes = append(sizes, len(bp.Points))
+		}
+		if len(sizes) != len(tt.sizes) {
+			t.Errorf("%s: batches of %v points, want %v", tt.name, sizes, tt.sizes)
+			continue
+		}
+		for i := range sizes {
+			if sizes[i] != tt.sizes[i] {
+				t.Errorf("%s: batches of %v points, want %v", tt.name, sizes, tt.sizes)
+				break
+			}
+		}
+	}
+}
+
+// maptransform writes every point to other/rp and drops the ones of drop.
+type maptransform struct{}
+
+func (maptransform) Map(db, rp string) (string, string) { return "other", rp }
+
+func (maptransform) Point(db, rp string, p *client.Point) bool {
+	return p.Measurement != "drop"
+}
+
+func TestChunkerTransform(t *testing.T) {
+	c := make(chan client.BatchPoints, 10)
+	ch := NewChunker("db", "rp", Options{MaxPoints: 10, Transform: maptransform{}}, c)
+	ch.Add(point("cpu"))
+	ch.Add(point("drop"))
+	ch.Add(point("mem"))
+	ch.Flush()
+	close(c)
+	bp := <-c
+	if bp.Database != "other" || len(bp.Points) != 2 {
+		t.Errorf("batch of %d points to %s, want 2 points to other", len(bp.Points), bp.Database)
+	}
+}
+
+func TestPointSize(t *testing.T) {
+	p := client.Point{
+		Measurement: "cpu",
+		Tags:        map[string]string{"host": "a"},
+		Fields:      map[string]interface{}{"value": 1.0, "s": "abc"},
+	}
+	// cpu+8, host=a+2, value+1+8, s+1+abc+2
+	if size, want := PointSize(p), 11+7+14+7; size != want {
+		t.Errorf("PointSize = %d, want %d", size, want)
+	}
+}
@@ -0,0 +1,97 @@
+package database
+
+import "sort"
+
+// ShardFields are the fields of every measurement stored in a shard, as
+// measurement name to field name to type, like float or string.
+type ShardFields struct {
+	Shard        Shard
+	Measurements map[string]map[string]string
+}
+
+// Measurements returns the names of the measurements of every shard, sorted.
+func Measurements(fields []ShardFields) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, sf := range fields {
+		for m := range sf.Measurements {
+			if !seen[m] {
+				seen[m] = true
+				names = append(names, m)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FieldConflict is a field stored with different types in the shards of a
+// database.
+type FieldConflict struct {
+	Database    string
+	Measurement string
+	Field       string
+	// Shards has the keys of the shards storing the field with each type.
+	Shards map[string][]string
+}
+
+// Conflicts returns the fields with more than one type among the shards of
+// each database, sorted by database, measurement and field.
+func Conflicts(fields []ShardFields) []FieldConflict {
+	index := make(map[[3]string]*FieldConflict)
+	var keys [][3]string
+	for _, sf := range fields {
+		for m, mfields := range sf.Measurements {
+			for f, t := range mfields {
+				k := [3]string{sf.Shard.Database, m, f}
+				c := index[k]
+				if c == nil {
+					c = &FieldConflict{Database: k[0], Measurement: m, Field: f, Shards: make(map[string][]string)}
+					index[k] = c
+					keys = append(keys, k)
+				}
+				c.Shards[t] = append(c.Shards[t], sf.Shard.Key())
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		for n := range keys[i] {
+			if keys[i][n] != keys[j][n] {
+				return keys[i][n] < keys[j][n]
+			}
+		}
+		return false
+	})
+
+	var conflicts []FieldConflict
+	for _, k := range keys {
+		if c := index[k]; len(c.Shards) > 1 {
+			conflicts = append(conflicts, *c)
+		}
+	}
+	return conflicts
+}
+
+// Types returns the types of the field, sorted.
+func (c FieldConflict) Types() []string {
+	var types []string
+	for t := range c.Shards {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Coercion returns the type every value of the field can be coerced to
+// losing the least: float when any of them is a float, integer for integers
+// and booleans. It is empty when some are strings, as the ones that aren't
+// numbers would be dropped, so the rule has to be chosen by hand.
+func (c FieldConflict) Coercion() string {
+	if len(c.Shards["string"]) > 0 {
+		return ""
+	}
+	if len(c.Shards["float"]) > 0 {
+		return "float"
+	}
+	return "integer"
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func shardfields(db, id string, measurements map[string]map[string]string) ShardFields {
+	return ShardFields{Shard: Shard{Database: db, RetentionPolicy: "rp", ID: id}, Measurements: measurements}
+}
+
+func TestConflicts(t *testing.T) {
+	fields := []ShardFields{
+		shardfields("a", "1", map[string]map[string]string{
+			"cpu": {"value": "float", "load": "integer", "host": "string"},
+			"mem": {"free": "integer"},
+		}),
+		shardfields("a", "2", map[string]map[string]string{
+			"cpu": {"value": "integer", "load": "integer", "host": "float"},
+		}),
+		shardfields("a", "3", map[string]map[string]string{
+			"cpu": {"value": "integer"},
+		}),
+		// another database with the same measurement doesn't conflict
+		shardfields("b", "4", map[string]map[string]string{
+			"mem": {"free": "float"},
+		}),
+	}
+	want := []FieldConflict{
+		{Database: "a", Measurement: "cpu", Field: "host", Shards: map[string][]string{"string": {"a/rp/1"}, "float": {"a/rp/2"}}},
+		{Database: "a", Measurement: "cpu", Field: "value", Shards: map[string][]string{"float": {"a/rp/1"}, "integer": {"a/rp/2", "a/rp/3"}}},
+	}
+	if got := Conflicts(fields); !reflect.DeepEqual(got, want) {
+		t.Errorf("Conflicts = %v, want %v", got, want)
+	}
+	if got := Conflicts(fields[:1]); len(got) != 0 {
+		t.Errorf("Conflicts of one shard = %v, want none", got)
+	}
+	if got, want := Measurements(fields), []string{"cpu", "mem"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Measurements = %v, want %v", got, want)
+	}
+}
+
+func TestCoercion(t *testing.T) {
+	tests := []struct {
+		types []string
+		want  string
+	}{
+		{types: []string{"float", "integer"}, want: "float"},
+		{types: []string{"boolean", "float"}, want: "float"},
+		{types: []string{"boolean", "integer"}, want: "integer"},
+		{types: []string{"float", "string"}, want: ""},
+		{types: []string{"integer", "string"}, want: ""},
+	}
+	for _, tt := range tests {
+		c := FieldConflict{Shards: make(map[string][]string)}
+		for _, typ := range tt.types {
+			c.Shards[typ] = []string{"db/rp/1"}
+		}
+		if got := c.Coercion(); got != tt.want {
+			t.Errorf("Coercion of %v = %q, want %q", tt.types, got, tt.want)
+		}
+		if got := c.Types(); !reflect.DeepEqual(got, tt.types) {
+			t.Errorf("Types = %v, want %v", got, tt.types)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"sync"
+)
+
+// Shard identifies a shard file of a retention policy.
+type Shard struct {
+	Database        string
+	RetentionPolicy string
+	ID              string
+	Path            string
+	// Series is the number of series in the shard, only known when the
+	// shard was listed by a Source Scan.
+	Series int
+}
+
+// Key identifies the shard among the shards of every database.
+func (sh Shard) Key() string {
+	return sh.Database + "/" + sh.RetentionPolicy + "/" + sh.ID
+}
+
+// ReadShards calls read for every shard from workers goroutines. Each call
+// must open its own handle to the shard. Once a shard fails no other shard is
+// started, and the error returned is the one of the first failing shard in
+// the order given, whatever the order the workers finished in. Once stop is
+// closed no other shard is started either.
+func ReadShards(shards []Shard, workers int, stop <-chan struct{}, read func(sh Shard) error) error {
+	return run(len(shards), workers, stop, func(i int) error {
+		return read(shards[i])
+	})
+}
+
+// ReadGroups is like ReadShards but each call reads a group of shards, like
+// the ones returned by GroupShards.
+func ReadGroups(groups [][]Shard, workers int, stop <-chan struct{}, read func(group []Shard) error) error {
+	return run(len(groups), workers, stop, func(i int) error {
+		return read(groups[i])
+	})
+}
+
+// GroupShards groups the shards by database and retention policy, keeping
+// the order they were found in.
+func GroupShards(shards []Shard) [][]Shard {
+	var groups [][]Shard
+	index := make(map[[2]string]int)
+	for _, sh := range shards {
+		k := [2]string{sh.Database, sh.RetentionPolicy}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], sh)
+	}
+	return groups
+}
+
+func run(n, workers int, stop <-chan struct{}, fn func(i int) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool
+		errs   = make([]error, n)
+		next   = make(chan int)
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := fn(i); err != nil {
+					mu.Lock()
+					errs[i] = err
+					failed = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+loop:
+	for i := 0; i < n; i++ {
+		mu.Lock()
+		halt := failed
+		mu.Unlock()
+		if halt || stopped(stop) {
+			break
+		}
+		select {
+		case next <- i:
+		case <-stop:
+			break loop
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopped tells whether stop was closed. A nil stop is never closed.
+func stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
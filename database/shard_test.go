@@ -0,0 +1,112 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func shards(n int) []Shard {
+	var ret []Shard
+	for i := 0; i < n; i++ {
+		ret = append(ret, Shard{Database: "db", RetentionPolicy: "rp", ID: fmt.Sprint(i)})
+	}
+	return ret
+}
+
+func TestReadShards(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 20} {
+		var (
+			mu   sync.Mutex
+			read []string
+		)
+		err := ReadShards(shards(10), workers, nil, func(sh Shard) error {
+			mu.Lock()
+			read = append(read, sh.ID)
+			mu.Unlock()
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%d workers: %v", workers, err)
+		}
+		sort.Strings(read)
+		if want := []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}; !reflect.DeepEqual(read, want) {
+			t.Errorf("%d workers read %v, want %v", workers, read, want)
+		}
+	}
+}
+
+func TestReadShardsFirstError(t *testing.T) {
+	// the later shard fails first, the error is still the one of the
+	// earlier shard
+	release := make(chan struct{})
+	err := ReadShards(shards(2), 2, nil, func(sh Shard) error {
+		if sh.ID == "0" {
+			<-release
+			return errors.New("shard 0")
+		}
+		close(release)
+		return errors.New("shard 1")
+	})
+	if err == nil || err.Error() != "shard 0" {
+		t.Errorf("error = %v, want shard 0", err)
+	}
+}
+
+func TestReadShardsStopAfterError(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		read int
+	)
+	err := ReadShards(shards(10), 1, nil, func(sh Shard) error {
+		mu.Lock()
+		read++
+		mu.Unlock()
+		return errors.New("failed")
+	})
+	if err == nil {
+		t.Fatalf("no error")
+	}
+	// the one failing and at most the one already handed to the worker
+	if read > 2 {
+		t.Errorf("%d shards read after the first one failed", read-1)
+	}
+}
+
+func TestReadShardsStopped(t *testing.T) {
+	stop := make(chan struct{})
+	close(stop)
+	read := 0
+	if err := ReadShards(shards(10), 1, stop, func(sh Shard) error {
+		read++
+		return nil
+	}); err != nil {
+		t.Errorf("error = %v", err)
+	}
+	if read != 0 {
+		t.Errorf("%d shards read once stopped", read)
+	}
+}
+
+func TestGroupShards(t *testing.T) {
+	in := []Shard{
+		{Database: "a", RetentionPolicy: "x", ID: "1"},
+		{Database: "b", RetentionPolicy: "x", ID: "2"},
+		{Database: "a", RetentionPolicy: "y", ID: "3"},
+		{Database: "a", RetentionPolicy: "x", ID: "4"},
+	}
+	var got [][]string
+	for _, g := range GroupShards(in) {
+		var ids []string
+		for _, sh := range g {
+			ids = append(ids, sh.ID)
+		}
+		got = append(got, ids)
+	}
+	if want := [][]string{{"1", "4"}, {"2"}, {"3"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupShards = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// Source reads one of the old versions.
+type Source struct {
+	// GetPoints sends every database and then the points of every shard,
+	// closing each channel once done.
+	GetPoints func(datapath string, opts Options, cdatabases chan<- Database, cpoints chan<- client.BatchPoints)
+	// Scan lists the shards with the number of series in each one, without
+	// reading any point.
+	Scan func(datapath string) ([]Shard, error)
+	// Databases lists the databases with their retention policies.
+	Databases func(datapath string) ([]Database, error)
+	// Fields lists the measurements of every shard with their fields, from
+	// the metadata the readers decode the points with.
+	Fields func(datapath string) ([]ShardFields, error)
+}
+
+var errStopped = errors.New("Readers stopped")
+
+// Progress is told about the points sent by the readers and the shards they
+// finished.
+type Progress interface {
+	Read(db, rp string, points int)
+	ShardStarted(sh Shard)
+	ShardDone(sh Shard)
+}
+
+// SendShards sends the points of the shards to cpoints in chunks, calling
+// read for every shard or, when the options ask for ordered points,
+// readordered for the shards of every retention policy. Shards in
+// opts.Done are left out. Once opts.Context is canceled the reads are
+// stopped and the shards being read aren't told to Progress as done; errors
+// of those reads are ignored as they are most likely due to the stop.
+func SendShards(shards []Shard,
+	opts Options,
+	cpoints chan<- client.BatchPoints,
+	read func(sh Shard, fn func(p client.Point) error) error,
+	readordered func(group []Shard, fn func(p client.Point) error) error) error {
+
+	stop := opts.stop()
+	send := func(group []Shard, read func(fn func(p client.Point) error) error) error {
+		if opts.Progress != nil {
+			for _, sh := range group {
+				opts.Progress.ShardStarted(sh)
+			}
+		}
+		chunker := NewChunker(group[0].Database, group[0].RetentionPolicy, opts, cpoints)
+		err := read(func(p client.Point) error {
+			select {
+			case <-stop:
+				return errStopped
+			default:
+			}
+			chunker.Add(p)
+			return nil
+		})
+		if stopped(stop) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		chunker.Flush()
+		if stopped(stop) {
+			return nil
+		}
+		if opts.Progress != nil {
+			for _, sh := range group {
+				opts.Progress.ShardDone(sh)
+			}
+		}
+		return nil
+	}
+
+	if len(opts.Done) > 0 {
+		var left []Shard
+		for _, sh := range shards {
+			if !opts.Done[sh.Key()] {
+				left = append(left, sh)
+			}
+		}
+		shards = left
+	}
+
+	if opts.Ordered {
+		return ReadGroups(GroupShards(shards), opts.Workers, stop, func(group []Shard) error {
+			return send(group, func(fn func(p client.Point) error) error {
+				return readordered(group, fn)
+			})
+		})
+	}
+	return ReadShards(shards, opts.Workers, stop, func(sh Shard) error {
+		return send([]Shard{sh}, func(fn func(p client.Point) error) error {
+			return read(sh, fn)
+		})
+	})
+}
@@ -0,0 +1,200 @@
+// Package destination compares what a migration writes with what the new
+// version already holds, so the points it would reject are found before
+// writing any of them.
+package destination
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/client"
+	"github.com/influxdb/influxdb/models"
+)
+
+// Field is a field written by the migration, with its type like float.
+type Field struct {
+	Database    string
+	Measurement string
+	Name        string
+	Type        string
+}
+
+// RetentionPolicy is a retention policy written to by the migration.
+type RetentionPolicy struct {
+	Database string
+	Name     string
+	Duration time.Duration
+	ReplicaN uint32
+}
+
+// Report is what the new version would reject or holds differently.
+type Report struct {
+	// Conflicts are the fields the new version has with another type, and
+	// the databases and retention policies it lacks, whose points it would
+	// reject.
+	Conflicts []string
+	// Warnings are the retention policies it has with another duration or
+	// replication, and the databases it can't tell the field types of.
+	Warnings []string
+}
+
+// Check compares the retention policies and fields with the ones of the new
+// version. Unless the migration creates them, missing databases and
+// retention policies are conflicts too. Servers before 1.0 don't report the
+// field types, so the fields of their databases aren't compared.
+func Check(c *client.Client, policies []RetentionPolicy, fields []Field, create bool) (*Report, error) {
+	existing, err := databases(c)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+
+	for _, db := range names(policies, fields) {
+		if !existing[db] {
+			if !create {
+				r.Conflicts = append(r.Conflicts, fmt.Sprintf("Database %s doesn't exist in the new version", db))
+			}
+			continue
+		}
+
+		rps, err := query(c, db, "show retention policies on "+quote(db))
+		if err != nil {
+			return nil, fmt.Errorf("Error reading retention policies of database %s: %v", db, err)
+		}
+		found := make(map[string]map[string]interface{})
+		for _, s := range rps {
+			for _, row := range rows(s) {
+				if name, ok := row["name"].(string); ok {
+					found[name] = row
+				}
+			}
+		}
+		for _, rp := range policies {
+			if rp.Database != db {
+				continue
+			}
+			row, ok := found[rp.Name]
+			if !ok {
+				if !create {
+					r.Conflicts = append(r.Conflicts, fmt.Sprintf(
+						"Retention policy %s doesn't exist on database %s in the new version", rp.Name, db))
+				}
+				continue
+			}
+			duration, _ := time.ParseDuration(fmt.Sprint(row["duration"]))
+			replican := fmt.Sprint(row["replicaN"])
+			if duration != rp.Duration || replican != fmt.Sprint(rp.ReplicaN) {
+				r.Warnings = append(r.Warnings, fmt.Sprintf(
+					"Retention policy %s on database %s exists with duration %v and replication %s, the old version has %v and %d",
+					rp.Name, db, duration, replican, rp.Duration, rp.ReplicaN))
+			}
+		}
+
+		keys, err := query(c, db, "show field keys")
+		if err != nil {
+			return nil, fmt.Errorf("Error reading field keys of database %s: %v", db, err)
+		}
+		types := make(map[[2]string]string)
+		typed := false
+		for _, s := range keys {
+			for _, row := range rows(s) {
+				name, _ := row["fieldKey"].(string)
+				if t, ok := row["fieldType"].(string); ok {
+					types[[2]string{s.Name, name}] = t
+					typed = true
+				}
+			}
+		}
+		if !typed {
+			if len(keys) > 0 {
+				r.Warnings = append(r.Warnings, fmt.Sprintf(
+					"Database %s already has fields but the server doesn't report their types, they can't be compared", db))
+			}
+			continue
+		}
+		for _, f := range fields {
+			if f.Database != db {
+				continue
+			}
+			if t, ok := types[[2]string{f.Measurement, f.Name}]; ok && t != f.Type {
+				r.Conflicts = append(r.Conflicts, fmt.Sprintf(
+					"Field %s of %s on database %s has type %s in the new version and %s in the old one",
+					f.Name, f.Measurement, db, t, f.Type))
+			}
+		}
+	}
+	return r, nil
+}
+
+// names returns the databases written to, sorted.
+func names(policies []RetentionPolicy, fields []Field) []string {
+	seen := make(map[string]bool)
+	for _, rp := range policies {
+		seen[rp.Database] = true
+	}
+	for _, f := range fields {
+		seen[f.Database] = true
+	}
+	var dbs []string
+	for db := range seen {
+		dbs = append(dbs, db)
+	}
+	sort.Strings(dbs)
+	return dbs
+}
+
+// databases returns the databases of the new version.
+func databases(c *client.Client) (map[string]bool, error) {
+	series, err := query(c, "", "show databases")
+	if err != nil {
+		return nil, fmt.Errorf("Error reading databases: %v", err)
+	}
+	dbs := make(map[string]bool)
+	for _, s := range series {
+		for _, row := range rows(s) {
+			if name, ok := row["name"].(string); ok {
+				dbs[name] = true
+			}
+		}
+	}
+	return dbs, nil
+}
+
+// query runs a statement, returning the series of its result.
+func query(c *client.Client, db, command string) ([]models.Row, error) {
+	resp, err := c.Query(client.Query{Command: command, Database: db})
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Error(); err != nil {
+		return nil, err
+	}
+	var series []models.Row
+	for _, res := range resp.Results {
+		series = append(series, res.Series...)
+	}
+	return series, nil
+}
+
+// rows returns the values of a series by column name, as the columns changed
+// between versions.
+func rows(s models.Row) []map[string]interface{} {
+	var ret []map[string]interface{}
+	for _, values := range s.Values {
+		row := make(map[string]interface{})
+		for i, v := range values {
+			if i < len(s.Columns) {
+				row[s.Columns[i]] = v
+			}
+		}
+		ret = append(ret, row)
+	}
+	return ret
+}
+
+// quote returns an identifier between double quotes.
+func quote(s string) string {
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
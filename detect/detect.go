@@ -0,0 +1,174 @@
+package detect
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// Result holds the version chosen for a datapath and why it was chosen.
+type Result struct {
+	Version string
+	Engines map[string]int
+	Reasons []string
+}
+
+func (r *Result) reason(format string, a ...interface{}) {
+	r.Reasons = append(r.Reasons, fmt.Sprintf(format, a...))
+}
+
+// String returns the engines found and how many shards use each one.
+func (r *Result) String() string {
+	var names []string
+	for k := range r.Engines {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	s := ""
+	for _, n := range names {
+		if s != "" {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s (%d shards)", n, r.Engines[n])
+	}
+	return s
+}
+
+// Detect inspects the datapath layout, the meta bucket names and the shard
+// engine formats to find out which reader is able to migrate it.
+func Detect(datapath string) (*Result, error) {
+	r := &Result{Engines: make(map[string]int)}
+
+	metapath := filepath.Join(datapath, "meta")
+	fi, err := os.Stat(metapath)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't find meta information at %s: %v", metapath, err)
+	}
+
+	if !fi.IsDir() {
+		r.reason("%s is a file", metapath)
+		ok, err := hasbucket(metapath, "Databases")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("Meta file %s doesn't have a Databases bucket", metapath)
+		}
+		r.reason("%s has a Databases bucket", metapath)
+		shardspath := filepath.Join(datapath, "shards")
+		if fi, err := os.Stat(shardspath); err != nil || !fi.IsDir() {
+			return nil, fmt.Errorf("Couldn't find shards directory %s", shardspath)
+		}
+		r.reason("shards are stored under %s", shardspath)
+		r.Engines["b1"] = countfiles(shardspath)
+		r.Version = "090rc31"
+		return r, nil
+	}
+
+	raftpath := filepath.Join(metapath, "raft.db")
+	ok, err := hasbucket(raftpath, "logs")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("Raft database %s doesn't have a logs bucket", raftpath)
+	}
+	r.reason("%s is a raft database with a logs bucket", raftpath)
+
+	shardspath := filepath.Join(datapath, "data")
+	if fi, err := os.Stat(shardspath); err != nil || !fi.IsDir() {
+		return nil, fmt.Errorf("Couldn't find data directory %s", shardspath)
+	}
+	r.reason("shards are stored under %s/<database>/<retention policy>", shardspath)
+
+	withformat := 0
+	shards, _ := filepath.Glob(filepath.Join(shardspath, "*", "*", "*"))
+	for _, sp := range shards {
+		if fi, err := os.Stat(sp); err != nil || fi.IsDir() {
+			continue
+		}
+		format, err := shardformat(sp)
+		if err != nil {
+			return nil, err
+		}
+		if format == "" {
+			r.Engines["b1"]++
+		} else {
+			withformat++
+			r.Engines[format]++
+		}
+	}
+
+	if withformat > 0 {
+		r.reason("%d of %d shards have the engine format in the meta bucket", withformat, len(shards))
+		r.Version = "092"
+	} else {
+		r.reason("no shard has a meta bucket, assuming the b1 engine")
+		r.Version = "090"
+	}
+	return r, nil
+}
+
+func open(path string) (*bolt.DB, error) {
+	return bolt.Open(
+		path,
+		0600,
+		&bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+}
+
+func hasbucket(path, name string) (bool, error) {
+	db, err := open(path)
+	if err != nil {
+		return false, fmt.Errorf("Error opening %s: %v", path, err)
+	}
+	defer db.Close()
+
+	var ok bool
+	err = db.View(func(tx *bolt.Tx) error {
+		ok = tx.Bucket([]byte(name)) != nil
+		return nil
+	})
+	return ok, err
+}
+
+// shardformat returns the engine format stored in the shard meta bucket or
+// an empty string when the shard predates it.
+func shardformat(path string) (string, error) {
+	db, err := open(path)
+	if err != nil {
+		return "", fmt.Errorf("Error opening shard %s: %v", path, err)
+	}
+	defer db.Close()
+
+	var format string
+	err = db.View(func(tx *bolt.Tx) error {
+		if mb := tx.Bucket([]byte("meta")); mb != nil {
+			if v := mb.Get([]byte("format")); v != nil {
+				format = string(v)
+			} else {
+				format = "b1"
+			}
+		}
+		return nil
+	})
+	return format, err
+}
+
+func countfiles(dir string) int {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,169 @@
+package b1
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/gogo/protobuf/proto"
+	"github.com/influxdb/influxdb/client"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/vladlopes/influxdb-migrate/engine"
+)
+
+// Format is the value stored in the shard meta bucket by the b1 engine.
+const Format = "b1"
+
+// Fields reads the measurement fields stored in the fields bucket.
+func Fields(tx *bolt.Tx) (map[string]*engine.MeasurementFields, error) {
+	measurements := make(map[string]*engine.MeasurementFields)
+	fb := tx.Bucket([]byte("fields"))
+	if fb == nil {
+		return nil, fmt.Errorf("Couldn't find bucket fields")
+	}
+	if err := fb.ForEach(func(k, v []byte) error {
+		mname := string(k)
+		mf, err := unmarshalfields(v)
+		if err != nil {
+			return fmt.Errorf("Error unmarshalling measurement %s: %v", mname, err)
+		}
+		measurements[mname] = mf
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return measurements, nil
+}
+
+// SeriesN returns the number of series buckets in the shard.
+func SeriesN(tx *bolt.Tx) int {
+	n := 0
+	tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if !reserved(string(name)) {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+// reserved tells if the bucket holds engine information instead of the
+// points of a series.
+func reserved(bname string) bool {
+	return bname == "fields" || bname == "series" || bname == "meta" || bname == "wal"
+}
+
+// Cursors returns a cursor for every series bucket in the shard and, when
+// there are points in the wal bucket, a last cursor over them.
+func Cursors(tx *bolt.Tx) ([]engine.Cursor, error) {
+	measurements, err := Fields(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursors []engine.Cursor
+	if err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		bname := string(name)
+		if reserved(bname) {
+			return nil
+		}
+		mname, tags, err := engine.ParseKey(bname, engine.B1Escapes)
+		if err != nil {
+			log.Printf("Skipping series: %v\n", err)
+			return nil
+		}
+		mf, ok := measurements[mname]
+		if !ok {
+			log.Printf("Couldn't find measurement %s in measurements\n", mname)
+			return nil
+		}
+
+		cursors = append(cursors, engine.NewBucketCursor(b, func(k, v []byte) (client.Point, error) {
+			fields, err := engine.DecodeFields(mname, mf, v)
+			if err != nil {
+				return client.Point{}, err
+			}
+			return client.Point{
+				Measurement: mname,
+				Time:        time.Unix(0, int64(engine.Btou64(k))),
+				Tags:        tags,
+				Fields:      fields,
+			}, nil
+		}))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	wal := tx.Bucket([]byte("wal"))
+	if wal == nil {
+		return cursors, nil
+	}
+	// The wal is flushed often, so its points are few enough to be sorted
+	// in memory.
+	var points []client.Point
+	if err := wal.ForEach(func(k, _ []byte) error {
+		pb := wal.Bucket(k)
+		if pb == nil {
+			return nil
+		}
+		return pb.ForEach(func(_, v []byte) error {
+			key, timestamp, data := unmarshalWALEntry(v)
+			mname, tags, err := engine.ParseKey(string(key), engine.B1Escapes)
+			if err != nil {
+				log.Printf("Skipping wal entry: %v\n", err)
+				return nil
+			}
+			mf, ok := measurements[mname]
+			if !ok {
+				log.Printf("Couldn't find measurement %s in measurements\n", mname)
+				return nil
+			}
+			fields, err := engine.DecodeFields(mname, mf, data)
+			if err != nil {
+				return err
+			}
+			points = append(points, client.Point{
+				Measurement: mname,
+				Time:        time.Unix(0, timestamp),
+				Tags:        tags,
+				Fields:      fields,
+			})
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	if len(points) > 0 {
+		cursors = append(cursors, engine.NewSliceCursor(points))
+	}
+	return cursors, nil
+}
+
+func unmarshalfields(buf []byte) (*engine.MeasurementFields, error) {
+	var pb MeasurementFields
+	if err := proto.Unmarshal(buf, &pb); err != nil {
+		return nil, err
+	}
+	m := &engine.MeasurementFields{Fields: make(map[string]*engine.Field)}
+	for _, f := range pb.Fields {
+		m.Fields[f.GetName()] = &engine.Field{ID: uint8(f.GetID()), Name: f.GetName(), Type: influxql.DataType(f.GetType())}
+	}
+	return m, nil
+}
+
+// unmarshalWALEntry decodes a wal bucket entry. The format is:
+//
+//	uint64 timestamp
+//	uint32 key length
+//	[]byte key
+//	[]byte data
+func unmarshalWALEntry(v []byte) (key []byte, timestamp int64, data []byte) {
+	keyLen := binary.BigEndian.Uint32(v[8:12])
+	key = v[12 : 12+keyLen]
+	timestamp = int64(binary.BigEndian.Uint64(v[0:8]))
+	data = v[12+keyLen:]
+	return
+}
@@ -14,7 +14,7 @@ It has these top-level messages:
 	MeasurementFields
 	Field
 */
-package from090
+package b1
 
 import proto "github.com/golang/protobuf/proto"
 import math "math"
@@ -0,0 +1,165 @@
+package bz1
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/snappy"
+	"github.com/influxdb/influxdb/client"
+	"github.com/vladlopes/influxdb-migrate/engine"
+)
+
+// Format is the value stored in the shard meta bucket by the bz1 engine.
+const Format = "bz1"
+
+// entryHeaderSize is the number of bytes required for the header.
+const entryHeaderSize = 8 + 4
+
+// entryDataSize returns the size of an entry's data field, in bytes.
+func entryDataSize(v []byte) int { return int(binary.BigEndian.Uint32(v[8:12])) }
+
+// Fields reads the snappy compressed measurement fields stored in the meta
+// bucket.
+func Fields(tx *bolt.Tx) (map[string]*engine.MeasurementFields, error) {
+	fb := tx.Bucket([]byte("meta"))
+	if fb == nil {
+		return nil, fmt.Errorf("Couldn't find bucket meta")
+	}
+	v := fb.Get([]byte("fields"))
+
+	data, err := snappy.Decode(nil, v)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding fields bucket: %v", err)
+	}
+
+	measurements := make(map[string]*engine.MeasurementFields)
+	if err := json.Unmarshal(data, &measurements); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling measurements: %v", err)
+	}
+	return measurements, nil
+}
+
+// SeriesN returns the number of series in the points bucket.
+func SeriesN(tx *bolt.Tx) (int, error) {
+	pb := tx.Bucket([]byte("points"))
+	if pb == nil {
+		return 0, fmt.Errorf("Couldn't find bucket points")
+	}
+	n := 0
+	err := pb.ForEach(func(_, v []byte) error {
+		if v == nil {
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Cursors returns a cursor for every series in the points bucket.
+func Cursors(tx *bolt.Tx) ([]engine.Cursor, error) {
+	measurements, err := Fields(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	pb := tx.Bucket([]byte("points"))
+	if pb == nil {
+		return nil, fmt.Errorf("Couldn't find bucket points")
+	}
+	var cursors []engine.Cursor
+	err = pb.ForEach(func(k, _ []byte) error {
+		bname := string(k)
+		mname, tags, err := engine.ParseKey(bname, engine.BZ1Escapes)
+		if err != nil {
+			return err
+		}
+		mf, ok := measurements[mname]
+		if !ok {
+			return fmt.Errorf("Couldn't find measurement %s in measurements", mname)
+		}
+
+		b := pb.Bucket(k)
+		if b == nil {
+			return fmt.Errorf("Error opening bucket %s", bname)
+		}
+		cursors = append(cursors, &cursor{
+			c:     b.Cursor(),
+			key:   bname,
+			mname: mname,
+			tags:  tags,
+			mf:    mf,
+		})
+		return nil
+	})
+	return cursors, err
+}
+
+// cursor walks the blocks of a series, whose keys are the big endian min time
+// of their entries, decoding a block only when the previous one is done.
+type cursor struct {
+	c       *bolt.Cursor
+	started bool
+	entries [][]byte
+
+	key   string
+	mname string
+	tags  map[string]string
+	mf    *engine.MeasurementFields
+}
+
+func (c *cursor) Next() (client.Point, bool, error) {
+	for len(c.entries) == 0 {
+		var k, v []byte
+		if !c.started {
+			k, v = c.c.First()
+			c.started = true
+		} else {
+			k, v = c.c.Next()
+		}
+		if k == nil {
+			return client.Point{}, false, nil
+		}
+		entries, err := blockentries(v)
+		if err != nil {
+			return client.Point{}, false, fmt.Errorf("Error decoding entry in %s: %v", c.key, err)
+		}
+		c.entries = entries
+	}
+
+	e := c.entries[0]
+	c.entries = c.entries[1:]
+	fields, err := engine.DecodeFields(c.mname, c.mf, e[entryHeaderSize:])
+	if err != nil {
+		return client.Point{}, false, err
+	}
+	return client.Point{
+		Measurement: c.mname,
+		Time:        time.Unix(0, int64(engine.Btou64(e[0:8]))),
+		Tags:        c.tags,
+		Fields:      fields,
+	}, true, nil
+}
+
+// blockentries splits a block into its entries. A block is the max time of
+// its entries followed by the snappy compressed entries.
+func blockentries(v []byte) ([][]byte, error) {
+	buf, err := snappy.Decode(nil, v[8:])
+	if err != nil {
+		return nil, err
+	}
+	var entries [][]byte
+	for {
+		if len(buf) == 0 {
+			break
+		}
+
+		dataSize := entryDataSize(buf)
+		entries = append(entries, buf[0:entryHeaderSize+dataSize])
+
+		buf = buf[entryHeaderSize+dataSize:]
+	}
+	return entries, nil
+}
@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"container/heap"
+	"sort"
+
+	"github.com/boltdb/bolt"
+	"github.com/influxdb/influxdb/client"
+)
+
+// Cursor iterates over the points of a series in ascending time order.
+type Cursor interface {
+	// Next returns the next point. ok is false once there are no more points.
+	Next() (p client.Point, ok bool, err error)
+}
+
+// DecodeFunc decodes the point stored under a big endian timestamp key.
+type DecodeFunc func(k, v []byte) (client.Point, error)
+
+type bucketCursor struct {
+	c      *bolt.Cursor
+	decode DecodeFunc
+	k, v   []byte
+	first  bool
+}
+
+// NewBucketCursor returns a cursor over a bucket whose keys are big endian
+// timestamps, which bolt already keeps in time order.
+func NewBucketCursor(b *bolt.Bucket, decode DecodeFunc) Cursor {
+	return &bucketCursor{c: b.Cursor(), decode: decode, first: true}
+}
+
+func (c *bucketCursor) Next() (client.Point, bool, error) {
+	if c.first {
+		c.k, c.v = c.c.First()
+		c.first = false
+	} else {
+		c.k, c.v = c.c.Next()
+	}
+	if c.k == nil {
+		return client.Point{}, false, nil
+	}
+	p, err := c.decode(c.k, c.v)
+	if err != nil {
+		return client.Point{}, false, err
+	}
+	return p, true, nil
+}
+
+type sliceCursor struct {
+	points []client.Point
+}
+
+// NewSliceCursor returns a cursor over points already in memory, sorting
+// them by time first.
+func NewSliceCursor(points []client.Point) Cursor {
+	sort.Stable(bytime(points))
+	return &sliceCursor{points: points}
+}
+
+func (c *sliceCursor) Next() (client.Point, bool, error) {
+	if len(c.points) == 0 {
+		return client.Point{}, false, nil
+	}
+	p := c.points[0]
+	c.points = c.points[1:]
+	return p, true, nil
+}
+
+type bytime []client.Point
+
+func (a bytime) Len() int           { return len(a) }
+func (a bytime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a bytime) Less(i, j int) bool { return a[i].Time.Before(a[j].Time) }
+
+// ForEach calls fn with the points of every cursor, one cursor after the
+// other.
+func ForEach(cursors []Cursor, fn func(p client.Point) error) error {
+	for _, c := range cursors {
+		for {
+			p, ok, err := c.Next()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				break
+			}
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Merge calls fn with the points of every cursor in global time order. Points
+// with the same time are passed in the order of their cursors.
+func Merge(cursors []Cursor, fn func(p client.Point) error) error {
+	h := &mergeheap{}
+	for i, c := range cursors {
+		p, ok, err := c.Next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.items = append(h.items, mergeitem{p: p, i: i})
+		}
+	}
+	heap.Init(h)
+
+	for h.Len() > 0 {
+		it := h.items[0]
+		if err := fn(it.p); err != nil {
+			return err
+		}
+		p, ok, err := cursors[it.i].Next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.items[0].p = p
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return nil
+}
+
+type mergeitem struct {
+	p client.Point
+	i int
+}
+
+type mergeheap struct {
+	items []mergeitem
+}
+
+func (h *mergeheap) Len() int { return len(h.items) }
+
+func (h *mergeheap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if a.p.Time.Equal(b.p.Time) {
+		return a.i < b.i
+	}
+	return a.p.Time.Before(b.p.Time)
+}
+
+func (h *mergeheap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *mergeheap) Push(x interface{}) { h.items = append(h.items, x.(mergeitem)) }
+
+func (h *mergeheap) Pop() interface{} {
+	it := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return it
+}
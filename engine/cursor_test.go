@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// points returns a point per second given, named after its cursor.
+func points(name string, secs ...int64) []client.Point {
+	var ret []client.Point
+	for _, s := range secs {
+		ret = append(ret, client.Point{Measurement: name, Time: time.Unix(s, 0)})
+	}
+	return ret
+}
+
+// collect returns the points fn is called with as name@seconds.
+func collect(t *testing.T, fn func(cursors []Cursor, fn func(p client.Point) error) error, cursors []Cursor) []string {
+	var got []string
+	if err := fn(cursors, func(p client.Point) error {
+		got = append(got, fmt.Sprintf("%s@%d", p.Measurement, p.Time.Unix()))
+		return nil
+	}); err != nil {
+		t.Fatalf("%v", err)
+	}
+	return got
+}
+
+func TestMerge(t *testing.T) {
+	cursors := []Cursor{
+		NewSliceCursor(points("a", 1, 4, 5)),
+		NewSliceCursor(points("b", 2, 4)),
+		NewSliceCursor(nil),
+		// unsorted, as the points of a wal are
+		NewSliceCursor(points("c", 6, 0, 4)),
+	}
+	got := collect(t, Merge, cursors)
+	want := []string{"c@0", "a@1", "b@2", "a@4", "b@4", "c@4", "a@5", "c@6"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %v, want %v", got, want)
+	}
+}
+
+func TestForEach(t *testing.T) {
+	cursors := []Cursor{
+		NewSliceCursor(points("a", 3, 1)),
+		NewSliceCursor(points("b", 2)),
+	}
+	got := collect(t, ForEach, cursors)
+	want := []string{"a@1", "a@3", "b@2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ForEach = %v, want %v", got, want)
+	}
+}
+
+type failing struct{}
+
+func (failing) Next() (client.Point, bool, error) { return client.Point{}, false, errors.New("broken") }
+
+func TestMergeError(t *testing.T) {
+	err := Merge([]Cursor{NewSliceCursor(points("a", 1)), failing{}}, func(client.Point) error { return nil })
+	if err == nil || err.Error() != "broken" {
+		t.Errorf("error = %v, want broken", err)
+	}
+	stop := errors.New("stop")
+	err = Merge([]Cursor{NewSliceCursor(points("a", 1, 2))}, func(client.Point) error { return stop })
+	if err != stop {
+		t.Errorf("error = %v, want the one of fn", err)
+	}
+}
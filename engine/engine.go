@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/boltdb/bolt"
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// DefaultFormat is the engine used by shards created before the format was
+// stored in the shard meta bucket (0.9.0 and 0.9.1).
+const DefaultFormat = "b1"
+
+type Field struct {
+	ID   uint8             `json:"id,omitempty"`
+	Name string            `json:"name,omitempty"`
+	Type influxql.DataType `json:"type,omitempty"`
+}
+
+type MeasurementFields struct {
+	Fields map[string]*Field `json:"fields"`
+}
+
+func (m *MeasurementFields) String() string {
+	b := &bytes.Buffer{}
+	b.WriteString("[")
+	for _, f := range m.Fields {
+		b.WriteString(fmt.Sprintf("%d %s %v |", f.ID, f.Name, f.Type))
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// Format returns the engine format of the shard opened by tx.
+func Format(tx *bolt.Tx) string {
+	if mb := tx.Bucket([]byte("meta")); mb != nil {
+		if v := mb.Get([]byte("format")); v != nil {
+			return string(v)
+		}
+	}
+	return DefaultFormat
+}
+
+// DecodeFields decodes the field values of a point encoded by the b1 and bz1
+// engines using the fields of the measurement.
+func DecodeFields(mname string, m *MeasurementFields, b []byte) (map[string]interface{}, error) {
+	ret := make(map[string]interface{})
+	for {
+		if len(b) < 1 {
+			break
+		}
+		fid := b[0]
+		var f *Field
+		for _, mf := range m.Fields {
+			if mf.ID == fid {
+				f = mf
+				break
+			}
+		}
+		if f == nil || f.ID == 0 {
+			return nil, fmt.Errorf("Couldn't find field %d in measurement %s", fid, mname)
+		}
+		var value interface{}
+		switch f.Type {
+		case influxql.Float:
+			value = math.Float64frombits(binary.BigEndian.Uint64(b[1:9]))
+			b = b[9:]
+		case influxql.Integer:
+			value = int64(binary.BigEndian.Uint64(b[1:9]))
+			b = b[9:]
+		case influxql.Boolean:
+			if b[1] == 1 {
+				value = true
+			} else {
+				value = false
+			}
+			b = b[2:]
+		case influxql.String:
+			size := binary.BigEndian.Uint16(b[1:3])
+			value = string(b[3 : size+3])
+			b = b[size+3:]
+		default:
+			return nil, fmt.Errorf("unsupported value type during decode fields: %s", f.Type)
+		}
+		ret[f.Name] = value
+	}
+	return ret, nil
+}
+
+func Btou64(b []byte) uint64 { return binary.BigEndian.Uint64(b) }
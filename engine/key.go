@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	inmeasurement = iota
+	intagkey
+	intagvalue
+)
+
+// Escapes are the characters a version of the server escaped with a
+// backslash in the measurement and in the tag keys and values of a key.
+type Escapes struct {
+	Measurement string
+	Tags        string
+}
+
+var (
+	// B1Escapes are the ones of 0.9.0 to 0.9.2, which wrote the b1 shards:
+	// commas, double quotes, spaces and equals signs everywhere.
+	B1Escapes = Escapes{Measurement: `, "=`, Tags: `, "=`}
+	// BZ1Escapes are the ones of the later versions, which wrote the bz1
+	// shards: commas and spaces in the measurement, and commas, spaces and
+	// equals signs in the tags.
+	BZ1Escapes = Escapes{Measurement: ", ", Tags: ", ="}
+)
+
+// ParseKey splits a series key into the measurement name and its tags.
+//
+// A backslash followed by a character the server escaped in that part of
+// the key is dropped and any other one is kept, as backslashes themselves
+// were never escaped. Only the first unescaped equals sign of a tag
+// separates its key from its value.
+func ParseKey(key string, escapes Escapes) (string, map[string]string, error) {
+	var (
+		b           bytes.Buffer
+		measurement string
+		tagkey      string
+		state       = inmeasurement
+		tags        = make(map[string]string)
+	)
+
+	// end finishes the token being read when a comma or the end of the key
+	// is found.
+	end := func(i int) error {
+		switch state {
+		case inmeasurement:
+			if b.Len() == 0 {
+				return fmt.Errorf("Empty measurement in series key %q", key)
+			}
+			measurement = b.String()
+		case intagkey:
+			return fmt.Errorf("Missing tag value at %d in series key %q", i, key)
+		case intagvalue:
+			tags[tagkey] = b.String()
+		}
+		b.Reset()
+		state = intagkey
+		return nil
+	}
+
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c == '\\' && i+1 < len(key) && escapes.escaped(state, key[i+1]):
+			i++
+			b.WriteByte(key[i])
+		case c == ',':
+			if err := end(i); err != nil {
+				return "", nil, err
+			}
+		case c == '=' && state == intagkey:
+			if b.Len() == 0 {
+				return "", nil, fmt.Errorf("Empty tag key at %d in series key %q", i, key)
+			}
+			tagkey = b.String()
+			b.Reset()
+			state = intagvalue
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if err := end(len(key)); err != nil {
+		return "", nil, err
+	}
+	return measurement, tags, nil
+}
+
+// escaped tells whether the server escaped the character in the part of the
+// key being read.
+func (e Escapes) escaped(state int, c byte) bool {
+	if state == inmeasurement {
+		return strings.IndexByte(e.Measurement, c) >= 0
+	}
+	return strings.IndexByte(e.Tags, c) >= 0
+}
@@ -0,0 +1,44 @@
+//go:build go1.18
+// +build go1.18
+
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func FuzzParseKey(f *testing.F) {
+	f.Add("cpu", "host", "a")
+	f.Add(`cpu\ load`, "host name", "a,b=c")
+	f.Add(`cpu"load`, `dir\name`, `c:\windows "x"`)
+	f.Fuzz(func(t *testing.T, measurement, tagkey, tagvalue string) {
+		// any key is either parsed or rejected
+		ParseKey(measurement+","+tagkey+"="+tagvalue, B1Escapes)
+		ParseKey(measurement+","+tagkey+"="+tagvalue, BZ1Escapes)
+
+		// a trailing backslash can't be told apart from the escape of the
+		// next separator
+		if measurement == "" || tagkey == "" || tagvalue == "" ||
+			strings.HasSuffix(measurement, `\`) || strings.HasSuffix(tagkey, `\`) || strings.HasSuffix(tagvalue, `\`) {
+			return
+		}
+		tags := map[string]string{tagkey: tagvalue}
+		roundtrip := func(k string, escapes Escapes) {
+			m, parsed, err := ParseKey(k, escapes)
+			if err != nil {
+				t.Fatalf("ParseKey(%q): %v", k, err)
+			}
+			if m != measurement || !reflect.DeepEqual(parsed, tags) {
+				t.Fatalf("ParseKey(%q) = %q, %v, want %q, %v", k, m, parsed, measurement, tags)
+			}
+		}
+		roundtrip(b1key(t, measurement, tags), B1Escapes)
+		// the later versions unescape the measurement before escaping it,
+		// so they can't tell these apart from the escaped ones
+		if !strings.Contains(measurement, `\,`) && !strings.Contains(measurement, `\ `) {
+			roundtrip(bz1key(t, measurement, tags), BZ1Escapes)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/models"
+)
+
+// bz1key returns the series key the versions writing bz1 shards build for a
+// measurement and tags.
+func bz1key(t testing.TB, name string, tags map[string]string) string {
+	p, err := models.NewPoint(name, models.Tags(tags), models.Fields{"value": 1.0}, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("NewPoint(%q, %v): %v", name, tags, err)
+	}
+	return string(p.Key())
+}
+
+// b1escaper escapes like the escapeCodes of tsdb/points.go of 0.9.0 to 0.9.2.
+var b1escaper = strings.NewReplacer(`,`, `\,`, `"`, `\"`, ` `, `\ `, `=`, `\=`)
+
+// b1key returns the series key 0.9.0 to 0.9.2 built for a measurement and
+// tags, which is the name of the series bucket of a b1 shard.
+func b1key(t testing.TB, name string, tags map[string]string) string {
+	var keys []string
+	for k, v := range tags {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	key := b1escaper.Replace(name)
+	for _, k := range keys {
+		key += "," + b1escaper.Replace(k) + "=" + b1escaper.Replace(tags[k])
+	}
+	return key
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		escapes     Escapes
+		key         string
+		measurement string
+		tags        map[string]string
+	}{
+		{name: "plain", measurement: "cpu", tags: map[string]string{"host": "a", "region": "west"}},
+		{name: "no tags", measurement: "cpu", tags: map[string]string{}},
+		{name: "escaped measurement", measurement: "cpu load,total", tags: map[string]string{"host": "a"}},
+		{name: "equals in measurement", measurement: "cpu=load", tags: map[string]string{"host": "a"}},
+		{name: "escaped tag key", measurement: "cpu", tags: map[string]string{"host name,fqdn=x": "a"}},
+		{name: "escaped tag value", measurement: "cpu", tags: map[string]string{"host": "a b,c=d"}},
+		{name: "unicode", measurement: "température", tags: map[string]string{"pièce": "salle à manger"}},
+		{name: "backslashes", measurement: `c:\temp`, tags: map[string]string{`dir\name`: `c:\windows\system32`}},
+		{name: "backslash before escape", measurement: "cpu", tags: map[string]string{"host": `a\ b`}},
+		{name: "quote", measurement: `cpu"load`, tags: map[string]string{"host": `a"b`}},
+		{
+			name:        "unescaped equals in tag value",
+			key:         "cpu,host=a=b,region=west",
+			measurement: "cpu",
+			tags:        map[string]string{"host": "a=b", "region": "west"},
+		},
+		{
+			name:        "bz1 never escaped quotes",
+			escapes:     BZ1Escapes,
+			key:         `cpu\"load,host=a\"b`,
+			measurement: `cpu\"load`,
+			tags:        map[string]string{"host": `a\"b`},
+		},
+		{
+			name:        "b1 escaped quotes",
+			escapes:     B1Escapes,
+			key:         `cpu\"load,host=a\"b`,
+			measurement: `cpu"load`,
+			tags:        map[string]string{"host": `a"b`},
+		},
+		{
+			name:        "b1 escaped equals in measurement",
+			escapes:     B1Escapes,
+			key:         `cpu\=load,host=a`,
+			measurement: "cpu=load",
+			tags:        map[string]string{"host": "a"},
+		},
+	}
+	for _, tt := range tests {
+		for _, e := range []struct {
+			engine  string
+			escapes Escapes
+			key     func(testing.TB, string, map[string]string) string
+		}{
+			{"b1", B1Escapes, b1key},
+			{"bz1", BZ1Escapes, bz1key},
+		} {
+			k := tt.key
+			if k == "" {
+				k = e.key(t, tt.measurement, tt.tags)
+			} else if tt.escapes != e.escapes && tt.escapes != (Escapes{}) {
+				continue
+			}
+			measurement, tags, err := ParseKey(k, e.escapes)
+			if err != nil {
+				t.Errorf("%s %s: ParseKey(%q): %v", e.engine, tt.name, k, err)
+				continue
+			}
+			if measurement != tt.measurement {
+				t.Errorf("%s %s: ParseKey(%q) measurement = %q, want %q", e.engine, tt.name, k, measurement, tt.measurement)
+			}
+			if !reflect.DeepEqual(tags, tt.tags) {
+				t.Errorf("%s %s: ParseKey(%q) tags = %v, want %v", e.engine, tt.name, k, tags, tt.tags)
+			}
+		}
+	}
+}
+
+func TestParseKeyErrors(t *testing.T) {
+	for _, k := range []string{"", ",host=a", "cpu,host", "cpu,=a", "cpu,host=a,"} {
+		for _, escapes := range []Escapes{B1Escapes, BZ1Escapes} {
+			if _, _, err := ParseKey(k, escapes); err == nil {
+				t.Errorf("ParseKey(%q, %v) returned no error", k, escapes)
+			}
+		}
+	}
+}
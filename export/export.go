@@ -0,0 +1,155 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/influxdb/influxdb/client"
+	"github.com/influxdb/influxdb/models"
+	"github.com/vladlopes/influxdb-migrate/database"
+)
+
+// The format is the one read by influx -import: the DDL statements after a
+// # DDL line and the points in line protocol after a # DML line, each batch
+// preceded by its database and retention policy when they change.
+const (
+	ddlheader = "# DDL"
+	dmlheader = "# DML"
+	dbcontext = "# CONTEXT-DATABASE: "
+	rpcontext = "# CONTEXT-RETENTION-POLICY: "
+)
+
+// Encoder writes statements and batches in the export format. It can be used
+// from several goroutines.
+type Encoder struct {
+	mu  sync.Mutex
+	out io.Writer
+	ddl bool
+	dml bool
+	db  string
+	rp  string
+}
+
+func NewEncoder(out io.Writer) *Encoder {
+	return &Encoder{out: out}
+}
+
+// DDL writes a statement. Statements must come before any batch.
+func (e *Encoder) DDL(stmt string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.ddl {
+		fmt.Fprintf(e.out, "%s\n", ddlheader)
+		e.ddl = true
+	}
+	fmt.Fprintf(e.out, "%s\n", stmt)
+}
+
+// Encode writes the points of the batch and returns how many of them were
+// written, with the error of the last one that couldn't be.
+func (e *Encoder) Encode(bp client.BatchPoints) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.dml {
+		fmt.Fprintf(e.out, "%s\n", dmlheader)
+		e.dml = true
+	}
+	if bp.Database != e.db {
+		fmt.Fprintf(e.out, "%s%s\n", dbcontext, bp.Database)
+		e.db = bp.Database
+	}
+	if bp.RetentionPolicy != e.rp {
+		fmt.Fprintf(e.out, "%s%s\n", rpcontext, bp.RetentionPolicy)
+		e.rp = bp.RetentionPolicy
+	}
+
+	var (
+		n       int
+		lasterr error
+	)
+	for _, p := range bp.Points {
+		sp, err := models.NewPoint(p.Measurement, p.Tags, p.Fields, p.Time)
+		if err != nil {
+			lasterr = fmt.Errorf("Error marshalling point %v to line protocol: %v", p, err)
+			continue
+		}
+		fmt.Fprintf(e.out, "%s\n", sp)
+		n++
+	}
+	return n, lasterr
+}
+
+// Read parses a file in the export format, calling ddl for every statement
+// and sending the points to cpoints in chunks bounded by opts. Lines before
+// any # DDL line are points. Reading stops
+// early once opts.Context is canceled. A point that can't be parsed is an
+// error with its line number.
+func Read(r io.Reader, opts database.Options, ddl func(stmt string), cpoints chan<- client.BatchPoints) error {
+	var (
+		db, rp  string
+		indml   = true
+		chunker *database.Chunker
+		line    int
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line++
+		if opts.Context != nil && opts.Context.Err() != nil {
+			return nil
+		}
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "":
+		case text == ddlheader:
+			indml = false
+		case text == dmlheader:
+			indml = true
+		case strings.HasPrefix(text, dbcontext):
+			db = strings.TrimSpace(strings.TrimPrefix(text, dbcontext))
+			chunker = flush(chunker)
+		case strings.HasPrefix(text, rpcontext):
+			rp = strings.TrimSpace(strings.TrimPrefix(text, rpcontext))
+			chunker = flush(chunker)
+		case strings.HasPrefix(text, "#"):
+		case !indml:
+			ddl(text)
+		default:
+			if db == "" {
+				return fmt.Errorf("Error in line %d: point before any %s line", line, strings.TrimSpace(dbcontext))
+			}
+			points, err := models.ParsePoints([]byte(text))
+			if err != nil {
+				return fmt.Errorf("Error in line %d: %v", line, err)
+			}
+			if chunker == nil {
+				chunker = database.NewChunker(db, rp, opts, cpoints)
+			}
+			for _, p := range points {
+				chunker.Add(client.Point{
+					Measurement: p.Name(),
+					Tags:        p.Tags(),
+					Fields:      p.Fields(),
+					Time:        p.Time(),
+				})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	flush(chunker)
+	return nil
+}
+
+// flush sends what the chunker has left, returning the nil chunker for the
+// next context.
+func flush(c *database.Chunker) *database.Chunker {
+	if c != nil {
+		c.Flush()
+	}
+	return nil
+}
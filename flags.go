@@ -0,0 +1,450 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/influxdb/influxdb/client"
+	"github.com/vladlopes/influxdb-migrate/config"
+	"github.com/vladlopes/influxdb-migrate/database"
+	"github.com/vladlopes/influxdb-migrate/export"
+	"github.com/vladlopes/influxdb-migrate/progress"
+	"github.com/vladlopes/influxdb-migrate/ratelimit"
+	"github.com/vladlopes/influxdb-migrate/transform"
+	"github.com/vladlopes/influxdb-migrate/writer"
+)
+
+const defaultdatapath = "/home/vagrant/.influxdbold/data"
+
+// sourceflags are the options of the commands reading the old version.
+type sourceflags struct {
+	fromversion *string
+	datapath    *string
+}
+
+func addsourceflags(fs *flag.FlagSet) *sourceflags {
+	return &sourceflags{
+		fromversion: fs.String(
+			"fromversion",
+			"auto",
+			fmt.Sprintf("From wich version to migrate (%s) or auto to detect it from the datapath", getversions())),
+		datapath: fs.String("datapath", defaultdatapath, "Location of the old version meta file and shards directory"),
+	}
+}
+
+func (f *sourceflags) check() error {
+	if _, ok := versions[*f.fromversion]; !ok && *f.fromversion != "auto" {
+		return fmt.Errorf("Invalid version %s. Valids: %s", *f.fromversion, getversions())
+	}
+	return nil
+}
+
+// source returns the reader of the version, detecting it first when auto.
+func (f *sourceflags) source() database.Source {
+	if *f.fromversion == "auto" {
+		v, err := detectversion(*f.datapath)
+		if err != nil {
+			log.Fatalf("%v\n", err)
+		}
+		*f.fromversion = v
+	}
+	return versions[*f.fromversion]
+}
+
+// shardflags are the options of the commands reading the shards of the old
+// version.
+type shardflags struct {
+	readers    *int
+	ordered    *bool
+	checkpoint *string
+}
+
+func addshardflags(fs *flag.FlagSet) *shardflags {
+	return &shardflags{
+		readers:    fs.Int("readers", 1, "Number of shards read at the same time"),
+		ordered:    fs.Bool("ordered", false, "Merge the shards of each retention policy to send their points in time order"),
+		checkpoint: fs.String("checkpoint", "", "File listing the shards already migrated, which are skipped; updated when the migration stops or completes"),
+	}
+}
+
+func (f *shardflags) check() error {
+	if *f.readers < 1 {
+		return fmt.Errorf("Invalid readers. Must be at least 1")
+	}
+	return nil
+}
+
+// readflags are the options of the commands sending points to be written.
+type readflags struct {
+	chunkpoints *int
+	chunkbytes  *int
+	maxmemory   *string
+}
+
+func addreadflags(fs *flag.FlagSet) *readflags {
+	return &readflags{
+		chunkpoints: fs.Int("chunkpoints", 5000, "Maximum points read before sending them to be written"),
+		chunkbytes:  fs.Int("chunkbytes", 0, "Maximum estimated bytes read before sending them to be written (0 for no limit)"),
+		maxmemory:   fs.String("max-memory", "", "Maximum estimated memory of points read but not written yet, like 512MB or 2GB (empty for no limit)"),
+	}
+}
+
+// fit raises the points per chunk to the largest write of -adaptive, as a
+// write never has more points than the chunk it comes from.
+func (f *readflags) fit(w *writeflags) {
+	if *w.adaptive && *f.chunkpoints < *w.maxpoints {
+		*f.chunkpoints = *w.maxpoints
+	}
+}
+
+func (f *readflags) check() error {
+	if *f.chunkpoints < 1 {
+		return fmt.Errorf("Invalid chunk points. Must be at least 1")
+	}
+	if *f.maxmemory != "" {
+		if _, err := parsebytes(*f.maxmemory); err != nil {
+			return fmt.Errorf("Invalid max memory %s: %v", *f.maxmemory, err)
+		}
+	}
+	return nil
+}
+
+func (f *readflags) budget() *database.Budget {
+	if *f.maxmemory == "" {
+		return nil
+	}
+	max, _ := parsebytes(*f.maxmemory)
+	return database.NewBudget(max)
+}
+
+// urlflags are the options of the commands talking to the new version.
+type urlflags struct {
+	writeurl *string
+}
+
+func addurlflags(fs *flag.FlagSet) *urlflags {
+	return &urlflags{
+		writeurl: fs.String("writeurl", "http://localhost:8086/", "Url of the new database version"),
+	}
+}
+
+func (f *urlflags) check() error {
+	if _, err := url.Parse(*f.writeurl); err != nil {
+		return fmt.Errorf("Invalid url to write %s: %v", *f.writeurl, err)
+	}
+	return nil
+}
+
+// client connects to the new version, printing its version.
+func (f *urlflags) client() *client.Client {
+	c, version := newclient(*f.writeurl)
+	fmt.Printf("Destination server version: %s\n", version)
+	return c
+}
+
+// writeflags are the options of the commands writing to the new version.
+type writeflags struct {
+	*urlflags
+	fs              *flag.FlagSet
+	betweenwrites   *time.Duration
+	pointsperwrite  *int
+	writers         *int
+	writeretries    *int
+	retrywait       *time.Duration
+	adaptive        *bool
+	targetlatency   *time.Duration
+	maxpoints       *int
+	pointspersecond *float64
+	bytespersecond  *float64
+	ratefile        *string
+	failures        *string
+}
+
+func addwriteflags(fs *flag.FlagSet) *writeflags {
+	return &writeflags{
+		urlflags:        addurlflags(fs),
+		fs:              fs,
+		betweenwrites:   fs.Duration("betweenwrites", 100*time.Millisecond, "Interval to wait between writes and database commands, 0 by default when -pointspersecond, -bytespersecond or -ratefile limit them"),
+		pointsperwrite:  fs.Int("pointsperwrite", 5000, "Points per write"),
+		writers:         fs.Int("writers", 1, "Number of writes sent at the same time"),
+		writeretries:    fs.Int("writeretries", 0, "Times a failed write is sent again"),
+		retrywait:       fs.Duration("retrywait", time.Second, "Wait before the first retry of a write, doubled on each following one"),
+		adaptive:        fs.Bool("adaptive", false, "Adapt the points per write to the write latency, starting from -pointsperwrite"),
+		targetlatency:   fs.Duration("targetlatency", 500*time.Millisecond, "Write latency to stay under when -adaptive"),
+		maxpoints:       fs.Int("maxpointsperwrite", 50000, "Maximum points per write when -adaptive, which reads chunks of as many points"),
+		pointspersecond: fs.Float64("pointspersecond", 0, "Maximum points written per second by all writers (0 for no limit)"),
+		bytespersecond:  fs.Float64("bytespersecond", 0, "Maximum estimated bytes written per second by all writers (0 for no limit)"),
+		ratefile:        fs.String("ratefile", "", "File with pointspersecond= and bytespersecond= lines read again on SIGHUP to change the rates"),
+		failures:        fs.String("failures", "", "File to save the batches that couldn't be written, to run replay with"),
+	}
+}
+
+func (f *writeflags) check() error {
+	if err := f.urlflags.check(); err != nil {
+		return err
+	}
+	if *f.pointsperwrite < 1 {
+		return fmt.Errorf("Invalid points per write. Must be at least 1")
+	}
+	if *f.writers < 1 {
+		return fmt.Errorf("Invalid writers. Must be at least 1")
+	}
+	// the fixed wait is only a default for the writes without a limiter,
+	// as it would cap them below the rates given
+	limited := *f.pointspersecond > 0 || *f.bytespersecond > 0 || *f.ratefile != ""
+	if limited && !config.Set(f.fs)["betweenwrites"] {
+		*f.betweenwrites = 0
+	}
+	if *f.adaptive && *f.maxpoints < *f.pointsperwrite {
+		return fmt.Errorf("Invalid max points per write. Must be at least the points per write")
+	}
+	if *f.adaptive && *f.targetlatency <= 0 {
+		return fmt.Errorf("Invalid target latency. Must be greater than 0")
+	}
+	return nil
+}
+
+// writer returns the writer to the client configured by the options.
+func (f *writeflags) writer(c *client.Client, budget *database.Budget, tracker *progress.Tracker) *writer.Writer {
+	limiter := ratelimit.New(*f.pointspersecond, *f.bytespersecond)
+	go f.reloadrates(limiter)
+
+	w := &writer.Writer{
+		Client:         c,
+		PointsPerWrite: *f.pointsperwrite,
+		Workers:        *f.writers,
+		BetweenWrites:  *f.betweenwrites,
+		Limiter:        limiter,
+		Budget:         budget,
+		Progress:       tracker,
+		Retries:        *f.writeretries,
+		RetryWait:      *f.retrywait,
+	}
+	if *f.adaptive {
+		w.Sizer = writer.NewSizer(*f.pointsperwrite, *f.maxpoints, *f.targetlatency)
+	}
+	if *f.failures != "" {
+		out, err := os.Create(*f.failures)
+		if err != nil {
+			log.Fatalf("Error creating failures file %s: %v\n", *f.failures, err)
+		}
+		w.Failures = export.NewEncoder(out)
+	}
+	return w
+}
+
+// reloadrates changes the rates of the limiter to the ones in -ratefile
+// every time a SIGHUP is received.
+func (f *writeflags) reloadrates(limiter *ratelimit.Limiter) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	for range c {
+		if *f.ratefile == "" {
+			log.Printf("Ignoring SIGHUP: no rate file to read\n")
+			continue
+		}
+		pps, bps, err := ratelimit.ReadFile(*f.ratefile)
+		if err != nil {
+			log.Printf("Error reading rates from %s: %v\n", *f.ratefile, err)
+			continue
+		}
+		limiter.SetRates(pps, bps)
+		log.Printf("Rates changed to %g points and %g bytes per second\n", pps, bps)
+	}
+}
+
+func (f *writeflags) sleep() {
+	if *f.betweenwrites > 0 {
+		time.Sleep(*f.betweenwrites)
+	}
+}
+
+// transformflags are the options changing the points on the way to the new
+// version.
+type transformflags struct {
+	maps      *stringlist
+	dbprefix  *string
+	dbsuffix  *string
+	renames   *stringlist
+	tags      *stringlist
+	converts  *stringlist
+	fields    *stringlist
+	sanitize  *stringlist
+	timeshift *time.Duration
+	precision *string
+	// typeconflicts is what is done with fields stored with different
+	// types in the shards of a database.
+	typeconflicts *string
+
+	chain *transform.Chain
+}
+
+func addtransformflags(fs *flag.FlagSet) *transformflags {
+	f := &transformflags{
+		maps:      &stringlist{},
+		dbprefix:  fs.String("dbprefix", "", "Prefix added to the databases no -map matches"),
+		dbsuffix:  fs.String("dbsuffix", "", "Suffix added to the databases no -map matches"),
+		renames:   &stringlist{},
+		tags:      &stringlist{},
+		converts:  &stringlist{},
+		fields:    &stringlist{},
+		sanitize:  &stringlist{},
+		timeshift: fs.Duration("timeshift", 0, "Offset added to every timestamp, like 8760h to move the points a year later or -1h"),
+		precision: fs.String("precision", "", "Precision to truncate the timestamps to and write them with: s, ms or us (empty for nanoseconds)"),
+		typeconflicts: fs.String("typeconflicts", "warn",
+			"What to do with fields stored with different types in the shards of a database: warn, fail, coerce to the type suggested, or ignore"),
+	}
+	fs.Var(f.maps, "map", "Database, or database/retention policy, to write the old ones to, like metrics=metrics_legacy or old_*/default=merged/* (repeatable)")
+	fs.Var(f.renames, "rename", "Regular expression matching whole measurement names and its replacement, like cpu_load_short=cpu or (.*)_total=${1} (repeatable)")
+	fs.Var(f.tags, "tag", "Tag rule, optionally scoped like db/measurement:, one of rename old=new, drop key, add key=value, map key old=new,... or map key @file (repeatable)")
+	fs.Var(f.converts, "convert", "Tag moved to a field or field moved to a tag, optionally scoped like db/measurement:, as tofield key or totag key (repeatable)")
+	fs.Var(f.fields, "field", "Field rule, optionally scoped like db/measurement:, one of drop f1,f2, keep f1,f2, rename old=new or coerce key float|integer (repeatable)")
+	fs.Var(f.sanitize, "sanitize", "Action for values the new version rejects, like nan=sentinel:-1, longstring=dropfield, newline=droppoint or empty=keep (repeatable)")
+	return f
+}
+
+// check parses the rules into the chain of transforms.
+func (f *transformflags) check() error {
+	switch *f.typeconflicts {
+	case "warn", "fail", "coerce", "ignore":
+	default:
+		return fmt.Errorf("Invalid type conflicts %s. Valids: warn, fail, coerce, ignore", *f.typeconflicts)
+	}
+	m, err := transform.NewMapping(*f.maps, *f.dbprefix, *f.dbsuffix)
+	if err != nil {
+		return err
+	}
+	f.chain = &transform.Chain{Mapping: m}
+	if f.chain.Sanitize, err = transform.NewSanitize(*f.sanitize); err != nil {
+		return err
+	}
+	if *f.timeshift != 0 || *f.precision != "" {
+		if f.chain.Time, err = transform.NewTime(*f.timeshift, *f.precision); err != nil {
+			return err
+		}
+	}
+	if len(*f.renames) > 0 {
+		if f.chain.Rename, err = transform.NewRename(*f.renames); err != nil {
+			return err
+		}
+	}
+	if len(*f.converts) > 0 {
+		if f.chain.Convert, err = transform.NewConvert(*f.converts); err != nil {
+			return err
+		}
+	}
+	if len(*f.fields) > 0 {
+		if f.chain.Fields, err = transform.NewFields(*f.fields); err != nil {
+			return err
+		}
+	}
+	if len(*f.tags) > 0 {
+		if f.chain.Tags, err = transform.NewTags(*f.tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks the transforms against the measurements of the old
+// version and looks for type conflicts, both from the fields of its shards,
+// before any point is read. It returns the fields when it read them.
+func (f *transformflags) validate(source database.Source, datapath string) []database.ShardFields {
+	if f.chain.Rename == nil && *f.typeconflicts == "ignore" {
+		return nil
+	}
+	fields := readfields(source, datapath)
+	if err := f.chain.Validate(database.Measurements(fields)); err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	if *f.typeconflicts == "ignore" {
+		return fields
+	}
+
+	conflicts := database.Conflicts(fields)
+	for _, c := range conflicts {
+		printconflict(os.Stderr, c)
+		switch {
+		case c.Coercion() == "":
+			fmt.Fprintf(os.Stderr, "  it has strings, so it isn't coerced: fix it with a -field rule for %s/%s\n", c.Database, c.Measurement)
+		case *f.typeconflicts == "coerce":
+			if f.chain.Fields == nil {
+				f.chain.Fields, _ = transform.NewFields(nil)
+			}
+			f.chain.Fields.Coerce(c.Database, c.Measurement, c.Field, c.Coercion())
+			fmt.Fprintf(os.Stderr, "  coercing it to %s\n", c.Coercion())
+		default:
+			fmt.Fprintf(os.Stderr, "  coerce it with -field '%s/%s: coerce %s %s' or -typeconflicts=coerce\n",
+				c.Database, c.Measurement, c.Field, c.Coercion())
+		}
+	}
+	if len(conflicts) > 0 && *f.typeconflicts == "fail" {
+		log.Fatalf("%d fields have type conflicts, refusing to continue with -typeconflicts=fail\n", len(conflicts))
+	}
+	return fields
+}
+
+// printconflict writes the types of a field and the shards storing each one.
+func printconflict(w io.Writer, c database.FieldConflict) {
+	var types []string
+	for _, t := range c.Types() {
+		types = append(types, fmt.Sprintf("%s in shards %s", t, strings.Join(c.Shards[t], ", ")))
+	}
+	fmt.Fprintf(w, "Field %s of %s on database %s has several types: %s\n",
+		c.Field, c.Measurement, c.Database, strings.Join(types, "; "))
+}
+
+// stringlist is a flag that can be given many times.
+type stringlist []string
+
+func (l *stringlist) String() string { return strings.Join(*l, ", ") }
+
+func (l *stringlist) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// reportflags are the options to follow and steer a running migration.
+type reportflags struct {
+	progressinterval *time.Duration
+	progressformat   *string
+	metricsaddr      *string
+	statsinterval    *time.Duration
+	statsdb          *string
+	statsurl         *string
+	controladdr      *string
+	shutdowntimeout  *time.Duration
+}
+
+func addreportflags(fs *flag.FlagSet) *reportflags {
+	return &reportflags{
+		progressinterval: fs.Duration("progressinterval", 10*time.Second, "Interval between progress reports (0 to disable)"),
+		progressformat:   fs.String("progressformat", "text", "Format of the progress reports: text or json (one object per line)"),
+		metricsaddr:      fs.String("metrics-addr", "", "Address to serve Prometheus metrics on /metrics, like :9100 (empty to disable)"),
+		statsinterval:    fs.Duration("statsinterval", 0, "Interval between writes of the migration stats to -statsdb (0 to disable)"),
+		statsdb:          fs.String("statsdb", "_migration", "Database to write the migration stats to"),
+		statsurl:         fs.String("statsurl", "", "Url of the server to write the migration stats to (empty for -writeurl)"),
+		controladdr:      fs.String("control-addr", "", "Address to serve the control API on, like :9101 (empty to disable)"),
+		shutdowntimeout:  fs.Duration("shutdowntimeout", 30*time.Second, "Time to wait for the batches already read to be written when stopping"),
+	}
+}
+
+func (f *reportflags) check() error {
+	if *f.progressformat != "text" && *f.progressformat != "json" {
+		return fmt.Errorf("Invalid progress format %s. Valids: text, json", *f.progressformat)
+	}
+	return nil
+}
+
+// totals tells whether the reports need the totals from a scan.
+func (f *reportflags) totals() bool {
+	return *f.progressinterval > 0 || *f.statsinterval > 0 || *f.metricsaddr != ""
+}
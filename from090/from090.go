@@ -2,11 +2,9 @@ package from090
 
 import (
 	"bytes"
-	"encoding/binary"
 	"fmt"
 	"io/ioutil"
 	"log"
-	"math"
 	"path/filepath"
 	"strings"
 	"time"
@@ -16,39 +14,122 @@ import (
 	"github.com/hashicorp/go-msgpack/codec"
 	"github.com/hashicorp/raft"
 	"github.com/influxdb/influxdb/client"
-	"github.com/influxdb/influxdb/influxql"
 	"github.com/vladlopes/influxdb-migrate/database"
+	"github.com/vladlopes/influxdb-migrate/engine"
+	"github.com/vladlopes/influxdb-migrate/engine/b1"
+	"github.com/vladlopes/influxdb-migrate/engine/bz1"
 )
 
-type replaceescaped struct {
-	newtoken string
-	replaced string
-}
+// GetPoints reads the versions from 0.9.0 to 0.9.4. All of them keep the meta
+// information in meta/raft.db and the shards in data/<database>/<rp>/<id>.
+// What changed between them is the shard contents, so the engine is chosen
+// for each shard:
+//
+//	0.9.0 and 0.9.1: b1 only, with fields, series and one bucket per series.
+//	0.9.2: the shard gains a meta bucket holding the engine format and b1
+//	       keeps not yet flushed points in a wal bucket.
+//	0.9.3 and 0.9.4: new shards may use bz1, with the fields in meta and the
+//	       compressed blocks of each series under the points bucket. Its wal
+//	       lives outside the shard file and isn't read.
+//
+// An upgraded datapath can hold shards of every one of them.
+func GetPoints(datapath string,
+	opts database.Options,
+	cdatabases chan<- database.Database,
+	cpoints chan<- client.BatchPoints) {
+
+	databases := getdatabases(datapath)
 
-var (
-	escapes = map[string]replaceescaped{
-		`\,`: replaceescaped{newtoken: `§_§a§`, replaced: `,`},
-		`\"`: replaceescaped{newtoken: `§_§b§`, replaced: `"`},
-		`\ `: replaceescaped{newtoken: `§_§c§`, replaced: ` `},
-		`\=`: replaceescaped{newtoken: `§_§d§`, replaced: `=`},
+	for _, db := range databases {
+		cdatabases <- db
 	}
-)
+	close(cdatabases)
 
-type measurement struct {
-	Name   string
-	Fields []field
+	shards := getshards(datapath, databases)
+	if err := database.SendShards(shards, opts, cpoints, readshard, readordered); err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	close(cpoints)
 }
 
-type field struct {
-	ID   uint8
-	Name string
-	Type influxql.DataType
+// Databases lists the databases with their retention policies from the
+// raft log.
+func Databases(datapath string) ([]database.Database, error) {
+	return getdatabases(datapath), nil
 }
 
-func GetPoints(datapath string,
-	cdatabases chan<- database.Database,
-	cpoints chan<- client.BatchPoints) {
+// Scan lists the shards of every retention policy and counts their series.
+func Scan(datapath string) ([]database.Shard, error) {
+	shards := getshards(datapath, getdatabases(datapath))
+	for i, sh := range shards {
+		shdb, err := openshard(sh)
+		if err != nil {
+			return nil, err
+		}
+		err = shdb.View(func(tx *bolt.Tx) error {
+			var err error
+			switch format := engine.Format(tx); format {
+			case b1.Format:
+				shards[i].Series = b1.SeriesN(tx)
+			case bz1.Format:
+				shards[i].Series, err = bz1.SeriesN(tx)
+			default:
+				err = fmt.Errorf("Unknown engine format %s", format)
+			}
+			return err
+		})
+		shdb.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning shard %s from rp %s on database %s: %v",
+				sh.ID, sh.RetentionPolicy, sh.Database, err)
+		}
+	}
+	return shards, nil
+}
+
+// Fields reads the fields of every shard, stored in the fields bucket by b1
+// and in the meta bucket by bz1.
+func Fields(datapath string) ([]database.ShardFields, error) {
+	var ret []database.ShardFields
+	for _, sh := range getshards(datapath, getdatabases(datapath)) {
+		shdb, err := openshard(sh)
+		if err != nil {
+			return nil, err
+		}
+		var mfs map[string]*engine.MeasurementFields
+		err = shdb.View(func(tx *bolt.Tx) error {
+			var err error
+			switch format := engine.Format(tx); format {
+			case b1.Format:
+				mfs, err = b1.Fields(tx)
+			case bz1.Format:
+				mfs, err = bz1.Fields(tx)
+			default:
+				err = fmt.Errorf("Unknown engine format %s", format)
+			}
+			return err
+		})
+		shdb.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Error reading fields of shard %s from rp %s on database %s: %v",
+				sh.ID, sh.RetentionPolicy, sh.Database, err)
+		}
+		sf := database.ShardFields{Shard: sh, Measurements: make(map[string]map[string]string)}
+		for m, mf := range mfs {
+			fields := make(map[string]string)
+			for _, f := range mf.Fields {
+				fields[f.Name] = f.Type.String()
+			}
+			sf.Measurements[m] = fields
+		}
+		ret = append(ret, sf)
+	}
+	return ret, nil
+}
 
+// getdatabases replays the raft log to find the databases and retention
+// policies that exist.
+func getdatabases(datapath string) []database.Database {
 	metapath := filepath.Join(datapath, "meta/raft.db")
 
 	meta, err := bolt.Open(
@@ -87,147 +168,110 @@ func GetPoints(datapath string,
 			log.Fatalf("Error closing raft database: %v\n", err)
 		}
 	}
+	return databases
+}
 
-	for _, db := range databases {
-		cdatabases <- db
-	}
-	close(cdatabases)
-
+// getshards lists the shard files of every retention policy.
+func getshards(datapath string, databases []database.Database) []database.Shard {
+	var shards []database.Shard
 	for _, db := range databases {
 		for _, rp := range db.Policies {
 			shardspath := filepath.Join(datapath, "data", db.Name, rp.Name)
-			shards, err := ioutil.ReadDir(shardspath)
+			sfs, err := ioutil.ReadDir(shardspath)
 			if err != nil {
 				continue
 			}
-			for _, sf := range shards {
-				shdb, err := bolt.Open(
-					filepath.Join(shardspath, sf.Name()),
-					0600,
-					&bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
-				if err != nil {
-					log.Fatalf("Error opening shard %s from rp %s on database %s: %v\n",
-						sf.Name(), rp.Name, db.Name, err)
-				}
-				err = shdb.View(func(tx *bolt.Tx) error {
-					measurements := make(map[string]*measurementFields)
-					fb := tx.Bucket([]byte("fields"))
-					if fb == nil {
-						log.Fatalf("Couldn't find bucket fields in shard %s\n", sf.Name())
-					}
-					fb.ForEach(func(k, v []byte) error {
-						mname := string(k)
-						mf := &measurementFields{}
-						err := mf.UnmarshalBinary(v)
-						if err != nil {
-							log.Fatalf("Error unmarshalling measurement %s: %v\n", mname, err)
-						}
-						measurements[mname] = mf
-						return nil
-					})
-					tx.ForEach(func(name []byte, b *bolt.Bucket) error {
-						bname := string(name)
-						if bname != "fields" && bname != "series" {
-							bnameescaped := bname
-							for k, v := range escapes {
-								bnameescaped = strings.Replace(bnameescaped, k, v.newtoken, -1)
-							}
-							bnamesplitted := strings.Split(bnameescaped, ",")
-							mname := bnamesplitted[0]
-							if _, ok := measurements[mname]; !ok {
-								fmt.Printf("Couldn't find measurement %s in measurements\n", mname)
-							} else {
-								tags := make(map[string]string)
-								for i := 1; i < len(bnamesplitted); i++ {
-									ts := strings.Split(bnamesplitted[i], "=")
-									tag := ts[1]
-									for _, v := range escapes {
-										tag = strings.Replace(tag, v.newtoken, v.replaced, -1)
-									}
-									tags[ts[0]] = tag
-								}
-
-								bp := client.BatchPoints{
-									Database:        db.Name,
-									RetentionPolicy: rp.Name,
-								}
-								b.ForEach(func(k, v []byte) error {
-									bp.Points = append(bp.Points, client.Point{
-										Measurement: mname,
-										Time:        time.Unix(0, int64(btou64(k))),
-										Tags:        tags,
-										Fields:      getfields(mname, measurements[mname], v),
-									})
-									return nil
-								})
-								cpoints <- bp
-							}
-						}
-						return nil
-					})
-					return nil
+			for _, sf := range sfs {
+				shards = append(shards, database.Shard{
+					Database:        db.Name,
+					RetentionPolicy: rp.Name,
+					ID:              sf.Name(),
+					Path:            filepath.Join(shardspath, sf.Name()),
 				})
-
-				if err != nil {
-					log.Fatalf("Error traversing shard %s from rp %s on database %s: %v\n",
-						sf.Name(), rp.Name, db.Name, err)
-				}
 			}
 		}
 	}
-	close(cpoints)
+	return shards
 }
 
-func btou64(b []byte) uint64 { return binary.BigEndian.Uint64(b) }
+func openshard(sh database.Shard) (*bolt.DB, error) {
+	shdb, err := bolt.Open(
+		sh.Path,
+		0600,
+		&bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("Error opening shard %s from rp %s on database %s: %v",
+			sh.ID, sh.RetentionPolicy, sh.Database, err)
+	}
+	return shdb, nil
+}
 
-func u64tob(v uint64) []byte {
-	b := make([]byte, 8)
-	binary.BigEndian.PutUint64(b, v)
-	return b
+// shardcursors returns the series cursors of the engine used by the shard.
+func shardcursors(tx *bolt.Tx) ([]engine.Cursor, error) {
+	switch format := engine.Format(tx); format {
+	case b1.Format:
+		return b1.Cursors(tx)
+	case bz1.Format:
+		return bz1.Cursors(tx)
+	default:
+		return nil, fmt.Errorf("Unknown engine format %s", format)
+	}
 }
 
-func getfields(mname string, m *measurementFields, b []byte) (ret map[string]interface{}) {
-	ret = make(map[string]interface{})
-	for {
-		if len(b) < 1 {
-			break
+// readshard opens its own handle to the shard and calls fn with every point
+// decoded by the shard engine, one series after the other.
+func readshard(sh database.Shard, fn func(p client.Point) error) error {
+	shdb, err := openshard(sh)
+	if err != nil {
+		return err
+	}
+	defer shdb.Close()
+
+	err = shdb.View(func(tx *bolt.Tx) error {
+		cursors, err := shardcursors(tx)
+		if err != nil {
+			return err
 		}
-		fid := b[0]
-		var f *field
-		for _, mf := range m.Fields {
-			if mf.ID == fid {
-				f = mf
-				break
-			}
+		return engine.ForEach(cursors, fn)
+	})
+	if err != nil {
+		return fmt.Errorf("Error traversing shard %s from rp %s on database %s: %v",
+			sh.ID, sh.RetentionPolicy, sh.Database, err)
+	}
+	return nil
+}
+
+// readordered opens every shard of a retention policy at once and calls fn
+// with their points in time order, merging the cursors of all their series.
+func readordered(shards []database.Shard, fn func(p client.Point) error) error {
+	var cursors []engine.Cursor
+	for _, sh := range shards {
+		shdb, err := openshard(sh)
+		if err != nil {
+			return err
 		}
-		if f.ID == 0 {
-			log.Fatalf("Couldn't find field %d in measurement %s\n", fid, mname)
+		defer shdb.Close()
+
+		tx, err := shdb.Begin(false)
+		if err != nil {
+			return fmt.Errorf("Error traversing shard %s from rp %s on database %s: %v",
+				sh.ID, sh.RetentionPolicy, sh.Database, err)
 		}
-		var value interface{}
-		switch f.Type {
-		case influxql.Float:
-			value = math.Float64frombits(binary.BigEndian.Uint64(b[1:9]))
-			b = b[9:]
-		case influxql.Integer:
-			value = int64(binary.BigEndian.Uint64(b[1:9]))
-			b = b[9:]
-		case influxql.Boolean:
-			if b[1] == 1 {
-				value = true
-			} else {
-				value = false
-			}
-			b = b[2:]
-		case influxql.String:
-			size := binary.BigEndian.Uint16(b[1:3])
-			value = string(b[3 : size+3])
-			b = b[size+3:]
-		default:
-			log.Fatalf("unsupported value type during decode fields: %s", f.Type)
+		defer tx.Rollback()
+
+		cs, err := shardcursors(tx)
+		if err != nil {
+			return fmt.Errorf("Error traversing shard %s from rp %s on database %s: %v",
+				sh.ID, sh.RetentionPolicy, sh.Database, err)
 		}
-		ret[f.Name] = value
+		cursors = append(cursors, cs...)
+	}
+
+	if err := engine.Merge(cursors, fn); err != nil {
+		return fmt.Errorf("Error merging shards from rp %s on database %s: %v",
+			shards[0].RetentionPolicy, shards[0].Database, err)
 	}
-	return
+	return nil
 }
 
 func decodeMsgPack(buf []byte, out interface{}) error {
@@ -247,6 +291,9 @@ func applycommand(dbs []database.Database, b []byte) []database.Database {
 	case Command_CreateDatabaseCommand:
 		ext, _ := proto.GetExtension(&cmd, E_CreateDatabaseCommand_Command)
 		v := ext.(*CreateDatabaseCommand)
+		if strings.HasSuffix(v.GetName(), "internal") {
+			break
+		}
 		updateddbs = append(updateddbs, database.Database{Name: v.GetName()})
 	case Command_DropDatabaseCommand:
 		ext, _ := proto.GetExtension(&cmd, E_DropDatabaseCommand_Command)
@@ -265,6 +312,9 @@ func applycommand(dbs []database.Database, b []byte) []database.Database {
 		for i, db := range updateddbs {
 			if db.Name == v.GetDatabase() {
 				rp := v.GetRetentionPolicy()
+				if strings.HasSuffix(rp.GetName(), "internal") {
+					continue
+				}
 				updateddbs[i].Policies = append(updateddbs[i].Policies,
 					database.RetentionPolicy{
 						Name:     rp.GetName(),
@@ -307,42 +357,3 @@ func applycommand(dbs []database.Database, b []byte) []database.Database {
 	}
 	return updateddbs
 }
-
-type measurementFields struct {
-	Fields map[string]*field `json:"fields"`
-}
-
-// MarshalBinary encodes the object to a binary format.
-func (m *measurementFields) MarshalBinary() ([]byte, error) {
-	var pb MeasurementFields
-	for _, f := range m.Fields {
-		id := int32(f.ID)
-		name := f.Name
-		t := int32(f.Type)
-		pb.Fields = append(pb.Fields, &Field{ID: &id, Name: &name, Type: &t})
-	}
-	return proto.Marshal(&pb)
-}
-
-// UnmarshalBinary decodes the object from a binary format.
-func (m *measurementFields) UnmarshalBinary(buf []byte) error {
-	var pb MeasurementFields
-	if err := proto.Unmarshal(buf, &pb); err != nil {
-		return err
-	}
-	m.Fields = make(map[string]*field)
-	for _, f := range pb.Fields {
-		m.Fields[f.GetName()] = &field{ID: uint8(f.GetID()), Name: f.GetName(), Type: influxql.DataType(f.GetType())}
-	}
-	return nil
-}
-
-func (m *measurementFields) String() string {
-	b := &bytes.Buffer{}
-	b.WriteString("[")
-	for _, f := range m.Fields {
-		b.WriteString(fmt.Sprintf("%d %s %v |", f.ID, f.Name, f.Type))
-	}
-	b.WriteString("]")
-	return b.String()
-}
@@ -3,6 +3,7 @@ package from090rc31
 import (
 	"encoding/binary"
 	"encoding/json"
+	"fmt"
 	"log"
 	"math"
 	"path/filepath"
@@ -12,6 +13,7 @@ import (
 	"github.com/boltdb/bolt"
 	"github.com/influxdb/influxdb/client"
 	"github.com/vladlopes/influxdb-migrate/database"
+	"github.com/vladlopes/influxdb-migrate/engine"
 )
 
 type versiondb struct {
@@ -54,9 +56,111 @@ type serie struct {
 }
 
 func GetPoints(datapath string,
+	opts database.Options,
 	cdatabases chan<- database.Database,
 	cpoints chan<- client.BatchPoints) {
 
+	databases := getdatabases(datapath)
+
+	for _, db := range databases {
+		cdatabases <- db.database()
+	}
+	close(cdatabases)
+
+	dbs := make(map[string]versiondb)
+	for _, db := range databases {
+		dbs[db.Name] = db
+	}
+	shards := getshards(datapath, databases)
+	err := database.SendShards(shards, opts, cpoints,
+		func(sh database.Shard, fn func(p client.Point) error) error {
+			return readshard(sh, dbs[sh.Database], fn)
+		},
+		func(group []database.Shard, fn func(p client.Point) error) error {
+			return readordered(group, dbs[group[0].Database], fn)
+		})
+	if err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	close(cpoints)
+}
+
+// Databases lists the databases with their retention policies from the meta
+// file.
+func Databases(datapath string) ([]database.Database, error) {
+	var ret []database.Database
+	for _, db := range getdatabases(datapath) {
+		ret = append(ret, db.database())
+	}
+	return ret, nil
+}
+
+// Scan lists the shards of every retention policy and counts their series,
+// each one being a bucket in the shard.
+func Scan(datapath string) ([]database.Shard, error) {
+	shards := getshards(datapath, getdatabases(datapath))
+	for i, sh := range shards {
+		shdb, err := openshard(sh)
+		if err != nil {
+			return nil, err
+		}
+		err = shdb.View(func(tx *bolt.Tx) error {
+			return tx.ForEach(func(_ []byte, _ *bolt.Bucket) error {
+				shards[i].Series++
+				return nil
+			})
+		})
+		shdb.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning shard %s from rp %s on database %s: %v",
+				sh.ID, sh.RetentionPolicy, sh.Database, err)
+		}
+	}
+	return shards, nil
+}
+
+// Fields lists for every shard the fields of its database, as this version
+// keeps them in the meta file for the whole database.
+func Fields(datapath string) ([]database.ShardFields, error) {
+	databases := getdatabases(datapath)
+	dbs := make(map[string]versiondb)
+	for _, db := range databases {
+		dbs[db.Name] = db
+	}
+	var ret []database.ShardFields
+	for _, sh := range getshards(datapath, databases) {
+		sf := database.ShardFields{Shard: sh, Measurements: make(map[string]map[string]string)}
+		for _, m := range dbs[sh.Database].Measurements {
+			fields := make(map[string]string)
+			for _, f := range m.Fields {
+				fields[f.Name] = f.Type
+			}
+			sf.Measurements[m.Name] = fields
+		}
+		ret = append(ret, sf)
+	}
+	return ret, nil
+}
+
+// database returns the database without what only this version needs.
+func (db versiondb) database() database.Database {
+	rdb := database.Database{
+		Name: db.Name,
+		DefaultRetentionPolicy: db.DefaultRetentionPolicy,
+	}
+	for _, rp := range db.Policies {
+		rdb.Policies = append(rdb.Policies, database.RetentionPolicy{
+			Name:     rp.Name,
+			Duration: rp.Duration,
+			ReplicaN: rp.ReplicaN,
+		})
+	}
+	return rdb
+}
+
+// getdatabases reads the databases with their retention policies, shards,
+// measurements and series from the meta file.
+func getdatabases(datapath string) []versiondb {
 	metapath := filepath.Join(datapath, "meta")
 
 	meta, err := bolt.Open(
@@ -141,71 +245,112 @@ func GetPoints(datapath string,
 		}
 	}
 
-	for _, db := range databases {
-		rdb := database.Database{
-			Name: db.Name,
-			DefaultRetentionPolicy: db.DefaultRetentionPolicy,
-		}
-		for _, rp := range db.Policies {
-			rdb.Policies = append(rdb.Policies, database.RetentionPolicy{
-				Name:     rp.Name,
-				Duration: rp.Duration,
-				ReplicaN: rp.ReplicaN,
-			})
-		}
-		cdatabases <- rdb
-	}
-	close(cdatabases)
+	return databases
+}
 
+// getshards lists the shards of every shard group of every retention policy.
+func getshards(datapath string, databases []versiondb) []database.Shard {
 	shardspath := filepath.Join(datapath, "shards")
+	var shards []database.Shard
 	for _, db := range databases {
 		for _, rp := range db.Policies {
 			for _, sg := range rp.ShardGroups {
 				for _, sh := range sg.Shards {
-					shdb, err := bolt.Open(
-						filepath.Join(shardspath, strconv.Itoa(sh.Id)),
-						0600,
-						&bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
-					if err != nil {
-						log.Fatalf("Error opening shard %d from rp %s on database %s: %v\n",
-							sh.Id, rp.Name, db.Name, err)
-					}
-					err = shdb.View(func(tx *bolt.Tx) error {
-						for _, m := range db.Measurements {
-							for _, s := range m.Series {
-								sb := tx.Bucket(u64tob(s.Id))
-								if sb == nil {
-									continue
-								}
-								bp := client.BatchPoints{
-									Database:        db.Name,
-									RetentionPolicy: rp.Name,
-								}
-								sb.ForEach(func(k, v []byte) error {
-									bp.Points = append(bp.Points, client.Point{
-										Measurement: m.Name,
-										Time:        time.Unix(0, int64(btou64(k))),
-										Tags:        s.Tags,
-										Fields:      getfields(m, v),
-									})
-									return nil
-								})
-								cpoints <- bp
-							}
-						}
-
-						return nil
+					id := strconv.Itoa(sh.Id)
+					shards = append(shards, database.Shard{
+						Database:        db.Name,
+						RetentionPolicy: rp.Name,
+						ID:              id,
+						Path:            filepath.Join(shardspath, id),
 					})
-
-					if err != nil {
-						log.Fatalf("Error traversing shard %d from rp %s on database %s: %v\n",
-							sh.Id, rp.Name, db.Name, err)
-					}
 				}
 			}
 		}
 	}
-	close(cpoints)
+	return shards
+}
+
+func openshard(sh database.Shard) (*bolt.DB, error) {
+	shdb, err := bolt.Open(
+		sh.Path,
+		0600,
+		&bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("Error opening shard %s from rp %s on database %s: %v",
+			sh.ID, sh.RetentionPolicy, sh.Database, err)
+	}
+	return shdb, nil
+}
+
+// shardcursors returns a cursor for every series of the database found in
+// the shard.
+func shardcursors(tx *bolt.Tx, db versiondb) []engine.Cursor {
+	var cursors []engine.Cursor
+	for _, m := range db.Measurements {
+		for _, s := range m.Series {
+			sb := tx.Bucket(u64tob(s.Id))
+			if sb == nil {
+				continue
+			}
+			m, s := m, s
+			cursors = append(cursors, engine.NewBucketCursor(sb, func(k, v []byte) (client.Point, error) {
+				return client.Point{
+					Measurement: m.Name,
+					Time:        time.Unix(0, int64(btou64(k))),
+					Tags:        s.Tags,
+					Fields:      getfields(m, v),
+				}, nil
+			}))
+		}
+	}
+	return cursors
+}
+
+// readshard opens its own handle to the shard and calls fn with every point
+// of every series of the database found in it.
+func readshard(sh database.Shard, db versiondb, fn func(p client.Point) error) error {
+	shdb, err := openshard(sh)
+	if err != nil {
+		return err
+	}
+	defer shdb.Close()
+
+	err = shdb.View(func(tx *bolt.Tx) error {
+		return engine.ForEach(shardcursors(tx, db), fn)
+	})
+	if err != nil {
+		return fmt.Errorf("Error traversing shard %s from rp %s on database %s: %v",
+			sh.ID, sh.RetentionPolicy, sh.Database, err)
+	}
+	return nil
+}
+
+// readordered opens every shard of a retention policy at once and calls fn
+// with their points in time order, merging the cursors of all their series.
+func readordered(shards []database.Shard, db versiondb, fn func(p client.Point) error) error {
+	var cursors []engine.Cursor
+	for _, sh := range shards {
+		shdb, err := openshard(sh)
+		if err != nil {
+			return err
+		}
+		defer shdb.Close()
+
+		tx, err := shdb.Begin(false)
+		if err != nil {
+			return fmt.Errorf("Error traversing shard %s from rp %s on database %s: %v",
+				sh.ID, sh.RetentionPolicy, sh.Database, err)
+		}
+		defer tx.Rollback()
+
+		cursors = append(cursors, shardcursors(tx, db)...)
+	}
+
+	if err := engine.Merge(cursors, fn); err != nil {
+		return fmt.Errorf("Error merging shards from rp %s on database %s: %v",
+			shards[0].RetentionPolicy, shards[0].Database, err)
+	}
+	return nil
 }
 
 func btou64(b []byte) uint64 { return binary.BigEndian.Uint64(b) }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/influxdb/influxdb/client"
+	"github.com/vladlopes/influxdb-migrate/export"
+	"github.com/vladlopes/influxdb-migrate/transform"
+)
+
+func importflags(fs *flag.FlagSet) (func() error, func([]string)) {
+	rd, wr, tr, rep := addreadflags(fs), addwriteflags(fs), addtransformflags(fs), addreportflags(fs)
+	nodbcmd := fs.Bool("nodbcmd", false, "Don't perform database commands")
+	return checks(rd.check, wr.check, tr.check, rep.check), func(args []string) {
+		c := wr.client()
+		writefile("Import", args, rd, wr, tr, rep, c, func(stmt string) {
+			if *nodbcmd {
+				return
+			}
+			if _, err := c.Query(client.Query{Command: stmt}); err != nil {
+				fmt.Printf("Error running %s: %v\n", stmt, err)
+			}
+			wr.sleep()
+		})
+	}
+}
+
+func replayflags(fs *flag.FlagSet) (func() error, func([]string)) {
+	rd, wr, rep := addreadflags(fs), addwriteflags(fs), addreportflags(fs)
+	// the batches saved were already transformed
+	tr := &transformflags{chain: &transform.Chain{}}
+	return checks(rd.check, wr.check, rep.check), func(args []string) {
+		if len(args) == 1 && args[0] == *wr.failures {
+			log.Fatalf("Invalid failures file %s: it is the file being replayed\n", *wr.failures)
+		}
+		c := wr.client()
+		writefile("Replay", args, rd, wr, tr, rep, c, func(stmt string) {
+			fmt.Printf("Ignoring statement %s: only points are replayed\n", stmt)
+		})
+	}
+}
+
+// writefile writes the points of the export file in args, - for stdin, to
+// the client, calling ddl with its statements.
+func writefile(name string, args []string, rd *readflags, wr *writeflags, tr *transformflags, rep *reportflags, c *client.Client, ddl func(stmt string)) {
+	if len(args) != 1 {
+		log.Fatalf("Usage: %s %s [options] file\n", os.Args[0], strings.ToLower(name))
+	}
+	var r io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			log.Fatalf("Error opening %s: %v\n", args[0], err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	rd.fit(wr)
+	p := newpipeline(name, os.Stdout, nil, "", rd.budget(), *wr.writers)
+	go func() {
+		if err := export.Read(r, p.options(rd, tr), ddl, p.cpoints); err != nil {
+			log.Fatalf("Error reading %s: %v\n", args[0], err)
+		}
+		close(p.cpoints)
+	}()
+	p.run(wr.writer(c, p.budget, p.tracker), rep, c, args[0])
+}
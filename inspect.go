@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/influxdb/influxdb/client"
+	"github.com/vladlopes/influxdb-migrate/database"
+	"github.com/vladlopes/influxdb-migrate/transform"
+)
+
+func inspectflags(fs *flag.FlagSet) (func() error, func([]string)) {
+	src := addsourceflags(fs)
+	return src.check, func([]string) {
+		source := src.source()
+		shards, err := source.Scan(*src.datapath)
+		if err != nil {
+			log.Fatalf("Error scanning %s: %v\n", *src.datapath, err)
+		}
+
+		var series int
+		for _, db := range databases(source, *src.datapath) {
+			fmt.Printf("database %s, default retention policy %s\n", db.Name, db.DefaultRetentionPolicy)
+			for _, rp := range db.Policies {
+				fmt.Printf("  retention policy %s: duration %v, replication %d\n", rp.Name, rp.Duration, rp.ReplicaN)
+				for _, sh := range shards {
+					if sh.Database == db.Name && sh.RetentionPolicy == rp.Name {
+						fmt.Printf("    shard %s: %d series, %s\n", sh.ID, sh.Series, sh.Path)
+						series += sh.Series
+					}
+				}
+			}
+		}
+		fmt.Printf("%d shards, %d series\n", len(shards), series)
+	}
+}
+
+func conflictsflags(fs *flag.FlagSet) (func() error, func([]string)) {
+	src := addsourceflags(fs)
+	return src.check, func([]string) {
+		source := src.source()
+		fields := readfields(source, *src.datapath)
+		conflicts := database.Conflicts(fields)
+		for _, c := range conflicts {
+			printconflict(os.Stdout, c)
+			if c.Coercion() == "" {
+				fmt.Printf("  it has strings: choose a -field rule for %s/%s by hand\n", c.Database, c.Measurement)
+				continue
+			}
+			fmt.Printf("  suggested: -field '%s/%s: coerce %s %s'\n", c.Database, c.Measurement, c.Field, c.Coercion())
+		}
+		if len(conflicts) > 0 {
+			fmt.Printf("%d fields have type conflicts\n", len(conflicts))
+			os.Exit(1)
+		}
+		fmt.Printf("No type conflicts in %d shards\n", len(fields))
+	}
+}
+
+// fieldkey identifies a field of a measurement.
+type fieldkey struct {
+	database        string
+	retentionpolicy string
+	measurement     string
+	field           string
+}
+
+func verifyflags(fs *flag.FlagSet) (func() error, func([]string)) {
+	src, u, tr := addsourceflags(fs), addurlflags(fs), addtransformflags(fs)
+	readers := fs.Int("readers", 1, "Number of shards read at the same time")
+	return checks(src.check, u.check, tr.check), func([]string) {
+		source := src.source()
+		tr.validate(source, *src.datapath)
+		c := u.client()
+
+		fmt.Printf("Counting the points of version %s...\n", *src.fromversion)
+		counts := make(map[fieldkey]int64)
+		cpoints := make(chan client.BatchPoints, *readers)
+		// the points are counted as they are written, after the transforms
+		opts := database.Options{MaxPoints: 5000, Workers: *readers, Transform: tr.chain}
+		go source.GetPoints(*src.datapath, opts, discard(), cpoints)
+		for bp := range cpoints {
+			for _, p := range bp.Points {
+				for f := range p.Fields {
+					counts[fieldkey{bp.Database, bp.RetentionPolicy, p.Measurement, f}]++
+				}
+			}
+		}
+
+		var keys []fieldkey
+		for k := range counts {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			a, b := keys[i], keys[j]
+			if a.database != b.database {
+				return a.database < b.database
+			}
+			if a.retentionpolicy != b.retentionpolicy {
+				return a.retentionpolicy < b.retentionpolicy
+			}
+			if a.measurement != b.measurement {
+				return a.measurement < b.measurement
+			}
+			return a.field < b.field
+		})
+
+		var mismatches int
+		for _, k := range keys {
+			n, err := count(c, k)
+			if err != nil {
+				log.Fatalf("Error counting field %s of %s in rp %s on database %s: %v\n",
+					k.field, k.measurement, k.retentionpolicy, k.database, err)
+			}
+			if n != counts[k] {
+				fmt.Printf("Mismatch in field %s of %s in rp %s on database %s: %d points in the old version, %d in the new one\n",
+					k.field, k.measurement, k.retentionpolicy, k.database, counts[k], n)
+				mismatches++
+			}
+		}
+		if mismatches > 0 {
+			fmt.Printf("%d of %d fields don't match\n", mismatches, len(keys))
+			os.Exit(1)
+		}
+		fmt.Printf("All %d fields match\n", len(keys))
+	}
+}
+
+// count returns the points of the field in the new version.
+func count(c *client.Client, k fieldkey) (int64, error) {
+	resp, err := c.Query(client.Query{
+		Command:  fmt.Sprintf("select count(%s) from %s.%s", quote(k.field), quote(k.retentionpolicy), quote(k.measurement)),
+		Database: k.database,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := resp.Error(); err != nil {
+		return 0, err
+	}
+	if len(resp.Results) == 0 || len(resp.Results[0].Series) == 0 {
+		return 0, nil
+	}
+	values := resp.Results[0].Series[0].Values
+	if len(values) == 0 || len(values[0]) < 2 {
+		return 0, nil
+	}
+	switch v := values[0][1].(type) {
+	case json.Number:
+		return v.Int64()
+	case float64:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("unexpected count %v", v)
+	}
+}
+
+// quote returns an identifier between double quotes.
+func quote(s string) string {
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
+
+func dryrunflags(fs *flag.FlagSet) (func() error, func([]string)) {
+	src, tr := addsourceflags(fs), addtransformflags(fs)
+	readers := fs.Int("readers", 1, "Number of shards read at the same time")
+	return checks(src.check, tr.check), func([]string) {
+		source := src.source()
+		tr.validate(source, *src.datapath)
+
+		fmt.Printf("Reading the points of version %s through the transforms...\n", *src.fromversion)
+		im := &impacts{chain: tr.chain, shapes: make(map[[2]string]*impact)}
+		cpoints := make(chan client.BatchPoints, *readers)
+		opts := database.Options{MaxPoints: 5000, Workers: *readers, Transform: im}
+		go source.GetPoints(*src.datapath, opts, discard(), cpoints)
+		for range cpoints {
+		}
+		im.print(os.Stdout)
+		tr.chain.Report(os.Stdout)
+	}
+}
+
+// impacts records the shape of every measurement of the old version before
+// and after the transforms. It implements database.Transform around them.
+type impacts struct {
+	chain  *transform.Chain
+	mu     sync.Mutex
+	shapes map[[2]string]*impact
+}
+
+// impact is the shape of a measurement of the old version and of where it
+// is written to.
+type impact struct {
+	before *shape
+	after  map[[2]string]*shape
+}
+
+// shape is what the points of a measurement look like.
+type shape struct {
+	points int
+	series map[string]bool
+	// tags has the values of each tag key and fields the types of each field.
+	tags   map[string]map[string]bool
+	fields map[string]map[string]bool
+}
+
+func newshape() *shape {
+	return &shape{series: make(map[string]bool), tags: make(map[string]map[string]bool), fields: make(map[string]map[string]bool)}
+}
+
+func (s *shape) add(p *client.Point) {
+	s.points++
+	var keys []string
+	for k, v := range p.Tags {
+		keys = append(keys, k+"="+v)
+		if s.tags[k] == nil {
+			s.tags[k] = make(map[string]bool)
+		}
+		s.tags[k][v] = true
+	}
+	sort.Strings(keys)
+	s.series[strings.Join(keys, ",")] = true
+	for k, v := range p.Fields {
+		if s.fields[k] == nil {
+			s.fields[k] = make(map[string]bool)
+		}
+		s.fields[k][fieldtype(v)] = true
+	}
+}
+
+// fieldtype returns the type of a field value like SHOW FIELD KEYS does.
+func fieldtype(v interface{}) string {
+	switch v.(type) {
+	case float64:
+		return "float"
+	case int64:
+		return "integer"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func (im *impacts) Map(db, rp string) (string, string) {
+	return im.chain.Map(db, rp)
+}
+
+func (im *impacts) Point(db, rp string, p *client.Point) bool {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	k := [2]string{db, p.Measurement}
+	i := im.shapes[k]
+	if i == nil {
+		i = &impact{before: newshape(), after: make(map[[2]string]*shape)}
+		im.shapes[k] = i
+	}
+	i.before.add(p)
+
+	if !im.chain.Point(db, rp, p) {
+		return false
+	}
+	todb, _ := im.chain.Map(db, rp)
+	to := [2]string{todb, p.Measurement}
+	if i.after[to] == nil {
+		i.after[to] = newshape()
+	}
+	i.after[to].add(p)
+	return true
+}
+
+// print writes the shape of every measurement before and after the
+// transforms, sorted by database and measurement.
+func (im *impacts) print(w io.Writer) {
+	var keys [][2]string
+	for k := range im.shapes {
+		keys = append(keys, k)
+	}
+	sortkeys(keys)
+	for _, k := range keys {
+		i := im.shapes[k]
+		fmt.Fprintf(w, "%s %s: ", k[0], k[1])
+		i.before.print(w, "  ")
+		var tos [][2]string
+		written := 0
+		for to, s := range i.after {
+			tos = append(tos, to)
+			written += s.points
+		}
+		sortkeys(tos)
+		for _, to := range tos {
+			fmt.Fprintf(w, "  -> %s %s: ", to[0], to[1])
+			i.after[to].print(w, "     ")
+		}
+		if dropped := i.before.points - written; dropped > 0 {
+			fmt.Fprintf(w, "  -> %d points dropped\n", dropped)
+		}
+	}
+}
+
+func (s *shape) print(w io.Writer, indent string) {
+	fmt.Fprintf(w, "%d points, %d series\n", s.points, len(s.series))
+	var tags []string
+	for k, values := range s.tags {
+		tags = append(tags, fmt.Sprintf("%s (%d values)", k, len(values)))
+	}
+	sort.Strings(tags)
+	fmt.Fprintf(w, "%stags: %s\n", indent, strings.Join(tags, ", "))
+	var fields []string
+	for k, types := range s.fields {
+		var ts []string
+		for t := range types {
+			ts = append(ts, t)
+		}
+		sort.Strings(ts)
+		fields = append(fields, k+" "+strings.Join(ts, "/"))
+	}
+	sort.Strings(fields)
+	fmt.Fprintf(w, "%sfields: %s\n", indent, strings.Join(fields, ", "))
+}
+
+func sortkeys(keys [][2]string) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+}
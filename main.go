@@ -1,138 +1,439 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"flag"
 	"fmt"
 	"log"
 	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/influxdb/influxdb/client"
-	"github.com/influxdb/influxdb/models"
+	"github.com/vladlopes/influxdb-migrate/config"
 	"github.com/vladlopes/influxdb-migrate/database"
+	"github.com/vladlopes/influxdb-migrate/destination"
+	"github.com/vladlopes/influxdb-migrate/detect"
+	"github.com/vladlopes/influxdb-migrate/export"
 	"github.com/vladlopes/influxdb-migrate/from090"
 	"github.com/vladlopes/influxdb-migrate/from090rc31"
-	"github.com/vladlopes/influxdb-migrate/from092"
+	"github.com/vladlopes/influxdb-migrate/transform"
+	"github.com/vladlopes/influxdb-migrate/writer"
 )
 
-var (
-	versions = map[string]func(string, chan<- database.Database, chan<- client.BatchPoints){
-		"090rc31": from090rc31.GetPoints,
-		"090":     from090.GetPoints,
-		"092":     from092.GetPoints,
-	}
-	fromversion = flag.String(
-		"fromversion",
-		"090rc31",
-		fmt.Sprintf("From wich version to migrate (%s)", getversions()))
-	datapath       = flag.String("datapath", "/home/vagrant/.influxdbold/data", "Location of the old version meta file and shards directory")
-	writeurl       = flag.String("writeurl", "http://localhost:8086/", "Url of the new database version")
-	betweenwrites  = flag.Duration("betweenwrites", 100*time.Millisecond, "Interval to wait between writes")
-	pointsperwrite = flag.Int("pointsperwrite", 5000, "Points per write")
-	onlyprint      = flag.Bool("onlyprint", false, "Only print points to stdout instead of sending to the server")
-	nodbcmd        = flag.Bool("nodbcmd", false, "Don't perform database commands")
-)
+var versions = map[string]database.Source{
+	"090rc31": {GetPoints: from090rc31.GetPoints, Scan: from090rc31.Scan, Databases: from090rc31.Databases, Fields: from090rc31.Fields},
+	"090":     {GetPoints: from090.GetPoints, Scan: from090.Scan, Databases: from090.Databases, Fields: from090.Fields},
+	// 0.9.2 onwards is read by from090, which chooses the engine per shard
+	"092": {GetPoints: from090.GetPoints, Scan: from090.Scan, Databases: from090.Databases, Fields: from090.Fields},
+}
+
+// command is a subcommand with its own options.
+type command struct {
+	name  string
+	args  string
+	usage string
+	// flags adds the options of the command to fs, returning what checks
+	// them once parsed and what runs the command with the arguments left.
+	flags func(fs *flag.FlagSet) (check func() error, run func(args []string))
+}
+
+var commands []command
+
+func init() {
+	// set here as config check looks the commands up
+	commands = []command{
+		{"migrate", "", "Read the old version and write its databases and points to the new one", migrateflags},
+		{"export", "", "Read the old version and write its databases and points in the format of influx -import", exportflags},
+		{"import", "file", "Write a file in the export format to the new version", importflags},
+		{"replay", "file", "Write again the batches saved with -failures", replayflags},
+		{"ddl", "", "Print, or run with -apply, the statements creating the databases and retention policies", ddlflags},
+		{"inspect", "", "List the databases, retention policies and shards of the old version", inspectflags},
+		{"conflicts", "", "List the fields stored with different types in the shards of a database", conflictsflags},
+		{"verify", "", "Compare the points of every field of the old version with the new one", verifyflags},
+		{"dryrun", "", "Read the old version through the transforms and show how they change the series, tags and fields", dryrunflags},
+		{"detect", "", "Detect the version of the old datapath", detectflags},
+		{"config", "check command [options]", "Validate the options of a command, with its -config file", configflags},
+	}
+}
 
 func main() {
-	flag.Parse()
+	// only the writers handle SIGHUP, to read -ratefile again; it doesn't
+	// end the other commands
+	signal.Ignore(syscall.SIGHUP)
 
-	if *pointsperwrite < 1 {
-		log.Fatalf("Invalid points per write. Must be at least 1")
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
 	}
+	name, args := os.Args[1], os.Args[2:]
+	if strings.HasPrefix(name, "-") && name != "-h" && name != "-help" {
+		// before the subcommands every option was a migrate one
+		name, args = "migrate", os.Args[1:]
+	}
+	switch name {
+	case "help", "-h", "-help":
+		usage()
+		return
+	}
+	cmd, ok := lookup(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %s\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+	fs, check, run := parse(cmd, args)
+	if err := check(); err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	run(fs.Args())
+}
 
-	cdatabases := make(chan database.Database)
-	cpoints := make(chan client.BatchPoints)
-	if f, ok := versions[*fromversion]; !ok {
-		log.Fatalf("Invalid version %s. Valids: %s", *fromversion, getversions())
-	} else {
-		go f(*datapath, cdatabases, cpoints)
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s command [options]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.usage)
 	}
+	fmt.Fprintf(os.Stderr, "\nRun %s command -h for the options of a command.\n", os.Args[0])
+}
 
-	var c *client.Client
-	if !*onlyprint {
-		u, err := url.Parse(*writeurl)
-		if err != nil {
-			log.Fatalf("Invalid url to write %s: %v\n", *writeurl, err)
+func lookup(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
 		}
-		c, err = client.NewClient(client.Config{
-			URL:       *u,
-			UserAgent: "influxdb-migrate",
-		})
+	}
+	return command{}, false
+}
+
+// parse parses the options of the command, setting the ones not given from
+// the environment and then from -config.
+func parse(cmd command, args []string) (*flag.FlagSet, func() error, func([]string)) {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	check, run := cmd.flags(fs)
+	configfile := fs.String("config", "", "TOML file with options named like the flags; flags and "+config.EnvPrefix+"* environment variables override it")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [options] %s\n\n%s.\n\nOptions:\n", os.Args[0], cmd.name, cmd.args, cmd.usage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	set := config.Set(fs)
+	if err := config.ApplyEnv(fs, set); err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	if *configfile != "" {
+		c, err := config.Load(*configfile, fs)
 		if err != nil {
-			log.Fatalf("Couldn't create client to write: %v\n", err)
+			log.Fatalf("%v\n", err)
 		}
-		_, toversion, err := c.Ping()
-		if err != nil {
-			log.Fatalf("Couldn't connect to server at %v: %v\n", writeurl, err)
+		if err := c.Apply(fs, set); err != nil {
+			log.Fatalf("%v\n", err)
 		}
-		fmt.Printf("Destination server version: %s\n", toversion)
 	}
+	return fs, check, run
+}
 
-	fmt.Printf("Starting migration from version %s...\n", *fromversion)
-
-	for db := range cdatabases {
-		dbcreatecmd := fmt.Sprintf("create database %s", db.Name)
-		if *onlyprint {
-			fmt.Printf("%s\n", dbcreatecmd)
-		} else if !*nodbcmd {
-			_, err := c.Query(client.Query{Command: dbcreatecmd})
-			if err != nil {
-				fmt.Printf("Error creating database %s: %v\n", db.Name, err)
+// checks returns a check running all of them in order.
+func checks(fns ...func() error) func() error {
+	return func() error {
+		for _, fn := range fns {
+			if err := fn(); err != nil {
+				return err
 			}
-			sleep()
 		}
-		for _, rp := range db.Policies {
-			var def string
-			if rp.Name == db.DefaultRetentionPolicy {
-				def = "default"
+		return nil
+	}
+}
+
+func nocheck() error { return nil }
+
+func migrateflags(fs *flag.FlagSet) (func() error, func([]string)) {
+	src, sh, rd, wr, tr, rep := addsourceflags(fs), addshardflags(fs), addreadflags(fs), addwriteflags(fs), addtransformflags(fs), addreportflags(fs)
+	nodbcmd := fs.Bool("nodbcmd", false, "Don't perform database commands")
+	destcheck := fs.String("destcheck", "warn", "What to do when the new version would reject points, like fields it has with other types: warn, fail or ignore")
+	checkdest := func() error {
+		if *destcheck != "warn" && *destcheck != "fail" && *destcheck != "ignore" {
+			return fmt.Errorf("Invalid destination check %s. Valids: warn, fail, ignore", *destcheck)
+		}
+		return nil
+	}
+	return checks(src.check, sh.check, rd.check, wr.check, tr.check, rep.check, checkdest), func([]string) {
+		source := src.source()
+		fields := tr.validate(source, *src.datapath)
+		dbs := databases(source, *src.datapath)
+		c := wr.client()
+		if *destcheck != "ignore" {
+			if fields == nil {
+				fields = readfields(source, *src.datapath)
 			}
-			rpcreatecmd := fmt.Sprintf("create retention policy %s on %s duration %du replication %d %s",
-				rp.Name, db.Name, rp.Duration.Nanoseconds()/int64(time.Microsecond), rp.ReplicaN, def)
-			if *onlyprint {
-				fmt.Printf("%s\n", rpcreatecmd)
-			} else if !*nodbcmd {
-				_, err := c.Query(client.Query{Command: rpcreatecmd})
-				if err != nil {
-					fmt.Printf("Error creating retention policy %s on database %s: %v\n", rp.Name, db.Name, err)
+			checkdestination(c, dbs, fields, tr.chain, !*nodbcmd, *destcheck == "fail")
+		}
+
+		rd.fit(wr)
+		p := newpipeline("Migration", os.Stdout, scan(source, *src.datapath, rep), *sh.checkpoint, rd.budget(), *wr.writers)
+		go source.GetPoints(*src.datapath, shardoptions(p, rd, tr, sh), discard(), p.cpoints)
+
+		fmt.Printf("Starting migration from version %s...\n", *src.fromversion)
+		if !*nodbcmd {
+			for _, stmt := range ddl(dbs, tr.chain) {
+				if _, err := c.Query(client.Query{Command: stmt}); err != nil {
+					fmt.Printf("Error running %s: %v\n", stmt, err)
 				}
-				sleep()
+				wr.sleep()
 			}
 		}
+
+		p.run(wr.writer(c, p.budget, p.tracker), rep, c, *src.datapath)
 	}
+}
 
-	for bp := range cpoints {
-		max := *pointsperwrite
-		points := bp.Points
-		for {
-			if len(points) < 1 {
-				break
+func exportflags(fs *flag.FlagSet) (func() error, func([]string)) {
+	src, sh, rd, tr, rep := addsourceflags(fs), addshardflags(fs), addreadflags(fs), addtransformflags(fs), addreportflags(fs)
+	out := fs.String("out", "-", "File to export to, - for stdout")
+	return checks(src.check, sh.check, rd.check, tr.check, rep.check), func([]string) {
+		source := src.source()
+		tr.validate(source, *src.datapath)
+		p := newpipeline("Export", os.Stderr, scan(source, *src.datapath, rep), *sh.checkpoint, rd.budget(), 1)
+
+		f := os.Stdout
+		if *out != "-" {
+			// with shards in the checkpoint a previous export is continued
+			flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			if len(p.done) > 0 {
+				flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
 			}
-			if len(points) < max {
-				max = len(points)
+			var err error
+			f, err = os.OpenFile(*out, flags, 0644)
+			if err != nil {
+				log.Fatalf("Error creating %s: %v\n", *out, err)
 			}
-			bp.Points = points[:max]
-			if !*onlyprint {
-				fmt.Printf(".")
-				_, err := c.Write(bp)
-				if err != nil {
-					fmt.Printf("Error writing batch points %v: %v\n", bp, err)
-				}
-			} else {
-				for _, p := range points {
-					if sp, err := models.NewPoint(p.Measurement, p.Tags, p.Fields, p.Time); err != nil {
-						fmt.Printf("Error marshalling point %v to line protocol: %v\n", p, err)
-					} else {
-						fmt.Printf("%s\n", sp)
-					}
+		}
+		b := bufio.NewWriter(f)
+		enc := export.NewEncoder(b)
+		p.flush = b.Flush
+
+		fmt.Fprintf(os.Stderr, "Starting export from version %s...\n", *src.fromversion)
+		for _, stmt := range ddl(databases(source, *src.datapath), tr.chain) {
+			enc.DDL(stmt)
+		}
+		go source.GetPoints(*src.datapath, shardoptions(p, rd, tr, sh), discard(), p.cpoints)
+
+		w := &writer.Writer{
+			Export:         enc,
+			PointsPerWrite: *rd.chunkpoints,
+			Workers:        1,
+			Budget:         p.budget,
+			Progress:       p.tracker,
+		}
+		p.run(w, rep, nil, *src.datapath)
+	}
+}
+
+func ddlflags(fs *flag.FlagSet) (func() error, func([]string)) {
+	src, u, tr := addsourceflags(fs), addurlflags(fs), addtransformflags(fs)
+	apply := fs.Bool("apply", false, "Run the statements on -writeurl instead of printing them")
+	return checks(src.check, u.check, tr.check), func([]string) {
+		source := src.source()
+		var c *client.Client
+		if *apply {
+			c = u.client()
+		}
+		for _, stmt := range ddl(databases(source, *src.datapath), tr.chain) {
+			if c == nil {
+				fmt.Printf("%s\n", stmt)
+			} else if _, err := c.Query(client.Query{Command: stmt}); err != nil {
+				fmt.Printf("Error running %s: %v\n", stmt, err)
+			}
+		}
+	}
+}
+
+func detectflags(fs *flag.FlagSet) (func() error, func([]string)) {
+	datapath := fs.String("datapath", defaultdatapath, "Location of the old version meta file and shards directory")
+	return nocheck, func([]string) {
+		if _, err := detectversion(*datapath); err != nil {
+			log.Fatalf("%v\n", err)
+		}
+	}
+}
+
+func configflags(fs *flag.FlagSet) (func() error, func([]string)) {
+	return nocheck, func(args []string) {
+		if len(args) < 2 || args[0] != "check" {
+			log.Fatalf("Usage: %s config check command [options]\n", os.Args[0])
+		}
+		cmd, ok := lookup(args[1])
+		if !ok || cmd.name == "config" {
+			log.Fatalf("Unknown command %s\n", args[1])
+		}
+		_, check, _ := parse(cmd, args[2:])
+		if err := check(); err != nil {
+			log.Fatalf("%v\n", err)
+		}
+		fmt.Printf("Configuration of %s is valid\n", cmd.name)
+	}
+}
+
+// scan returns the shards of the old version when the reports need their
+// totals.
+func scan(source database.Source, datapath string, r *reportflags) []database.Shard {
+	if !r.totals() {
+		return nil
+	}
+	shards, err := source.Scan(datapath)
+	if err != nil {
+		log.Fatalf("Error scanning %s: %v\n", datapath, err)
+	}
+	return shards
+}
+
+// shardoptions returns the options for reading the shards of the old version
+// into the pipeline.
+func shardoptions(p *pipeline, rd *readflags, tr *transformflags, sh *shardflags) database.Options {
+	opts := p.options(rd, tr)
+	opts.Workers = *sh.readers
+	opts.Ordered = *sh.ordered
+	return opts
+}
+
+// discard returns a channel dropping the databases sent by GetPoints, as the
+// commands read them with Databases first.
+func discard() chan database.Database {
+	c := make(chan database.Database)
+	go func() {
+		for range c {
+		}
+	}()
+	return c
+}
+
+// databases returns the databases of the old version sorted by name.
+func databases(source database.Source, datapath string) []database.Database {
+	dbs, err := source.Databases(datapath)
+	if err != nil {
+		log.Fatalf("Error reading the databases of %s: %v\n", datapath, err)
+	}
+	sort.Slice(dbs, func(i, j int) bool { return dbs[i].Name < dbs[j].Name })
+	return dbs
+}
+
+// readfields returns the fields of every shard of the old version.
+func readfields(source database.Source, datapath string) []database.ShardFields {
+	fields, err := source.Fields(datapath)
+	if err != nil {
+		log.Fatalf("Error reading the fields of %s: %v\n", datapath, err)
+	}
+	return fields
+}
+
+// checkdestination compares the retention policies and fields written, where
+// the transforms send them, with the ones of the new version, printing what
+// it would reject and exiting when refuse.
+func checkdestination(c *client.Client, dbs []database.Database, fields []database.ShardFields, t *transform.Chain, create, refuse bool) {
+	var policies []destination.RetentionPolicy
+	seenrp := make(map[destination.RetentionPolicy]bool)
+	for _, db := range dbs {
+		for _, rp := range db.Policies {
+			todb, torp := t.Map(db.Name, rp.Name)
+			p := destination.RetentionPolicy{Database: todb, Name: torp, Duration: rp.Duration, ReplicaN: rp.ReplicaN}
+			if !seenrp[p] {
+				seenrp[p] = true
+				policies = append(policies, p)
+			}
+		}
+	}
+	var written []destination.Field
+	seenf := make(map[destination.Field]bool)
+	for _, sf := range fields {
+		todb, _ := t.Map(sf.Shard.Database, sf.Shard.RetentionPolicy)
+		for m, mfields := range sf.Measurements {
+			for name, typ := range mfields {
+				tom, toname, totyp, ok := t.Field(sf.Shard.Database, m, name, typ)
+				f := destination.Field{Database: todb, Measurement: tom, Name: toname, Type: totyp}
+				if ok && !seenf[f] {
+					seenf[f] = true
+					written = append(written, f)
 				}
 			}
-			points = points[max:]
-			sleep()
 		}
 	}
 
-	fmt.Printf("\nMigration completed!\n")
+	r, err := destination.Check(c, policies, written, create)
+	if err != nil {
+		log.Fatalf("Error checking the new version: %v\n", err)
+	}
+	sort.Strings(r.Warnings)
+	sort.Strings(r.Conflicts)
+	for _, w := range r.Warnings {
+		fmt.Printf("Warning: %s\n", w)
+	}
+	for _, c := range r.Conflicts {
+		fmt.Printf("Conflict: %s\n", c)
+	}
+	if len(r.Conflicts) > 0 {
+		if refuse {
+			log.Fatalf("The new version would reject the points of %d conflicts, refusing to continue with -destcheck=fail\n", len(r.Conflicts))
+		}
+		fmt.Printf("The new version will reject the points of %d conflicts, -field rules can change their fields\n", len(r.Conflicts))
+	}
+}
+
+// ddl returns the statements creating the databases and their retention
+// policies where the transform maps them to. Databases merged by the
+// mapping get each statement once.
+func ddl(dbs []database.Database, t database.Transform) []string {
+	var stmts []string
+	seen := make(map[string]bool)
+	add := func(stmt string) {
+		if !seen[stmt] {
+			seen[stmt] = true
+			stmts = append(stmts, stmt)
+		}
+	}
+	for _, db := range dbs {
+		for _, rp := range db.Policies {
+			todb, torp := t.Map(db.Name, rp.Name)
+			add(fmt.Sprintf("create database %s", todb))
+			var def string
+			if rp.Name == db.DefaultRetentionPolicy {
+				def = "default"
+			}
+			add(fmt.Sprintf("create retention policy %s on %s duration %du replication %d %s",
+				torp, todb, rp.Duration.Nanoseconds()/int64(time.Microsecond), rp.ReplicaN, def))
+		}
+		if len(db.Policies) == 0 {
+			todb, _ := t.Map(db.Name, "")
+			add(fmt.Sprintf("create database %s", todb))
+		}
+	}
+	return stmts
+}
+
+// newclient returns a client to the server at rawurl and its version.
+func newclient(rawurl string) (*client.Client, string) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		log.Fatalf("Invalid url to write %s: %v\n", rawurl, err)
+	}
+	c, err := client.NewClient(client.Config{
+		URL:       *u,
+		UserAgent: "influxdb-migrate",
+	})
+	if err != nil {
+		log.Fatalf("Couldn't create client to write: %v\n", err)
+	}
+	_, version, err := c.Ping()
+	if err != nil {
+		log.Fatalf("Couldn't connect to server at %v: %v\n", rawurl, err)
+	}
+	return c, version
 }
 
 func getversions() string {
@@ -143,8 +444,49 @@ func getversions() string {
 	return b.String()
 }
 
-func sleep() {
-	if *betweenwrites > 0 {
-		time.Sleep(*betweenwrites)
+// detectversion prints to stderr, to keep stdout clean for export, what the
+// version was detected from.
+func detectversion(datapath string) (string, error) {
+	r, err := detect.Detect(datapath)
+	if err != nil {
+		return "", fmt.Errorf("Couldn't detect version of %s: %v", datapath, err)
+	}
+	for _, reason := range r.Reasons {
+		fmt.Fprintf(os.Stderr, "Detect: %s\n", reason)
+	}
+	if len(r.Engines) > 0 {
+		fmt.Fprintf(os.Stderr, "Detect: engines %s\n", r)
+	}
+	fmt.Fprintf(os.Stderr, "Detected version: %s\n", r.Version)
+	return r.Version, nil
+}
+
+// parsebytes parses a size like 100KB, 512MB or 2GB into bytes.
+func parsebytes(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		mult   int64
+	}{
+		{"KB", 1 << 10},
+		{"MB", 1 << 20},
+		{"GB", 1 << 30},
+		{"B", 1},
+	}
+	mult := int64(1)
+	v := strings.ToUpper(strings.TrimSpace(s))
+	for _, u := range units {
+		if strings.HasSuffix(v, u.suffix) {
+			mult = u.mult
+			v = strings.TrimSpace(strings.TrimSuffix(v, u.suffix))
+			break
+		}
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("must be at least 1 byte")
 	}
+	return n * mult, nil
 }
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vladlopes/influxdb-migrate/progress"
+	"github.com/vladlopes/influxdb-migrate/writer"
+)
+
+// Handler serves the migration metrics in the Prometheus text format.
+type Handler struct {
+	Tracker *progress.Tracker
+	Writer  *writer.Writer
+	// QueueDepth returns the batches read waiting for a writer.
+	QueueDepth func() int
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b := &bytes.Buffer{}
+
+	counts := h.Tracker.Counts()
+	var rps []progress.RetentionPolicy
+	for rp := range counts {
+		rps = append(rps, rp)
+	}
+	sort.Sort(byname(rps))
+	for _, m := range []struct {
+		name, help string
+		value      func(c progress.Counts) int64
+	}{
+		{"influxdb_migrate_points_read_total", "Points decoded by the readers.",
+			func(c progress.Counts) int64 { return c.Read }},
+		{"influxdb_migrate_points_written_total", "Points written to the destination.",
+			func(c progress.Counts) int64 { return c.Written }},
+		{"influxdb_migrate_points_failed_total", "Points the destination didn't accept.",
+			func(c progress.Counts) int64 { return c.Failed }},
+	} {
+		header(b, m.name, m.help, "counter")
+		for _, rp := range rps {
+			fmt.Fprintf(b, "%s{database=%s,retention_policy=%s} %d\n",
+				m.name, quote(rp.Database), quote(rp.Name), m.value(counts[rp]))
+		}
+	}
+
+	s := h.Tracker.Snapshot()
+	header(b, "influxdb_migrate_write_retries_total", "Writes sent again after failing.", "counter")
+	fmt.Fprintf(b, "influxdb_migrate_write_retries_total %d\n", s.Retries)
+	header(b, "influxdb_migrate_bytes_sent_total", "Estimated bytes of the points written.", "counter")
+	fmt.Fprintf(b, "influxdb_migrate_bytes_sent_total %d\n", s.BytesSent)
+	header(b, "influxdb_migrate_shards_done", "Shards completely read.", "gauge")
+	fmt.Fprintf(b, "influxdb_migrate_shards_done %d\n", s.ShardsDone)
+	header(b, "influxdb_migrate_shards", "Shards found by the scan of the old version.", "gauge")
+	fmt.Fprintf(b, "influxdb_migrate_shards %d\n", s.Shards)
+
+	header(b, "influxdb_migrate_current_shard", "Shards being read.", "gauge")
+	for _, sh := range h.Tracker.Current() {
+		fmt.Fprintf(b, "influxdb_migrate_current_shard{database=%s,retention_policy=%s,shard=%s} 1\n",
+			quote(sh.Database), quote(sh.RetentionPolicy), quote(sh.ID))
+	}
+
+	if h.QueueDepth != nil {
+		header(b, "influxdb_migrate_reader_queue_depth", "Batches read waiting for a writer.", "gauge")
+		fmt.Fprintf(b, "influxdb_migrate_reader_queue_depth %d\n", h.QueueDepth())
+	}
+
+	hist := h.Writer.LatencyHistogram()
+	header(b, "influxdb_migrate_write_latency_seconds", "Latency of the writes to the destination.", "histogram")
+	for i, le := range writer.LatencyBuckets {
+		var c uint64
+		if hist.Counts != nil {
+			c = hist.Counts[i]
+		}
+		fmt.Fprintf(b, "influxdb_migrate_write_latency_seconds_bucket{le=\"%s\"} %d\n",
+			strconv.FormatFloat(le, 'g', -1, 64), c)
+	}
+	fmt.Fprintf(b, "influxdb_migrate_write_latency_seconds_bucket{le=\"+Inf\"} %d\n", hist.Count)
+	fmt.Fprintf(b, "influxdb_migrate_write_latency_seconds_sum %g\n", hist.Sum)
+	fmt.Fprintf(b, "influxdb_migrate_write_latency_seconds_count %d\n", hist.Count)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(b.Bytes())
+}
+
+func header(b *bytes.Buffer, name, help, typ string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+var labelescaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quote returns a label value escaped and between double quotes.
+func quote(s string) string {
+	return `"` + labelescaper.Replace(s) + `"`
+}
+
+type byname []progress.RetentionPolicy
+
+func (a byname) Len() int      { return len(a) }
+func (a byname) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byname) Less(i, j int) bool {
+	if a[i].Database == a[j].Database {
+		return a[i].Name < a[j].Name
+	}
+	return a[i].Database < a[j].Database
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/influxdb/influxdb/client"
+	"github.com/vladlopes/influxdb-migrate/checkpoint"
+	"github.com/vladlopes/influxdb-migrate/control"
+	"github.com/vladlopes/influxdb-migrate/database"
+	"github.com/vladlopes/influxdb-migrate/metrics"
+	"github.com/vladlopes/influxdb-migrate/progress"
+	"github.com/vladlopes/influxdb-migrate/transform"
+	"github.com/vladlopes/influxdb-migrate/writer"
+)
+
+// Exit codes of a migration that didn't complete. Errors exit with 1.
+const (
+	// exitstopped is used when the migration was stopped and every batch
+	// already read was written.
+	exitstopped = 3
+	// exitundrained is used when the migration was stopped without waiting
+	// for every batch already read to be written.
+	exitundrained = 4
+)
+
+// pipeline moves the batches sent by a reader to a writer, reporting the
+// progress and stopping cleanly on a signal or from the control API.
+type pipeline struct {
+	// name starts the messages, like Migration or Export.
+	name string
+	// messages gets what is printed besides the progress reports.
+	messages io.Writer
+	tracker  *progress.Tracker
+	budget   *database.Budget
+	cpoints  chan client.BatchPoints
+	ctx      context.Context
+	cancel   context.CancelFunc
+	// done has the keys of the shards already migrated according to
+	// checkpointfile, if any.
+	checkpointfile string
+	done           map[string]bool
+	// flush, when set, is called once the writer is done.
+	flush func() error
+	// transform is the one given to the readers by options.
+	transform *transform.Chain
+}
+
+// newpipeline returns a pipeline whose progress totals are the shards given,
+// left out the ones already in the checkpoint file.
+func newpipeline(name string, messages io.Writer, shards []database.Shard, checkpointfile string, budget *database.Budget, writers int) *pipeline {
+	done := map[string]bool{}
+	if checkpointfile != "" {
+		var err error
+		done, err = checkpoint.Read(checkpointfile)
+		if err != nil {
+			log.Fatalf("%v\n", err)
+		}
+		if len(done) > 0 {
+			fmt.Fprintf(messages, "Skipping %d shards already migrated according to %s\n", len(done), checkpointfile)
+		}
+	}
+	var left []database.Shard
+	for _, sh := range shards {
+		if !done[sh.Key()] {
+			left = append(left, sh)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &pipeline{
+		name:           name,
+		messages:       messages,
+		tracker:        progress.NewTracker(left),
+		budget:         budget,
+		cpoints:        make(chan client.BatchPoints, writers),
+		ctx:            ctx,
+		cancel:         cancel,
+		checkpointfile: checkpointfile,
+		done:           done,
+	}
+}
+
+// options returns the options for the readers sending to the pipeline.
+func (p *pipeline) options(r *readflags, t *transformflags) database.Options {
+	p.transform = t.chain
+	return database.Options{
+		MaxPoints: *r.chunkpoints,
+		MaxBytes:  *r.chunkbytes,
+		Budget:    p.budget,
+		Progress:  p.tracker,
+		Done:      p.done,
+		Context:   p.ctx,
+		Transform: t.chain,
+		Precision: t.chain.Precision(),
+	}
+}
+
+// run writes every batch with w until the readers close cpoints or the
+// pipeline is stopped, then writes the checkpoint and a summary and exits
+// when the migration didn't complete. stats writes the migration stats, and
+// may be nil when there is nowhere to write them.
+func (p *pipeline) run(w *writer.Writer, r *reportflags, stats *client.Client, source string) {
+	// stopping resumes the writers so the batches already read get written
+	var stoponce sync.Once
+	stop := func() {
+		stoponce.Do(func() {
+			p.cancel()
+			w.Resume()
+		})
+	}
+	go interrupted(stop)
+
+	if *r.metricsaddr != "" {
+		go func() {
+			h := &metrics.Handler{
+				Tracker:    p.tracker,
+				Writer:     w,
+				QueueDepth: func() int { return len(p.cpoints) },
+			}
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", h)
+			log.Fatalf("Error serving metrics on %s: %v\n", *r.metricsaddr, http.ListenAndServe(*r.metricsaddr, mux))
+		}()
+	}
+
+	snapshot := func() progress.Snapshot {
+		s := p.tracker.Snapshot()
+		s.PointsPerWrite = w.CurrentPointsPerWrite()
+		s.LatencyP50, s.LatencyP90, s.LatencyP99 = w.Latencies()
+		s.MemoryUsed, s.MemoryMax = p.budget.Used()
+		return s
+	}
+	if *r.progressinterval > 0 {
+		go progress.Report(os.Stderr, *r.progressinterval, *r.progressformat == "json", snapshot)
+	}
+	if *r.statsinterval > 0 {
+		if *r.statsurl != "" {
+			stats, _ = newclient(*r.statsurl)
+		}
+		if stats == nil {
+			log.Fatalf("Invalid stats interval: no server to write the stats to, set -statsurl")
+		}
+		go progress.Publish(stats, *r.statsdb, source, *r.statsinterval, p.tracker, snapshot)
+	}
+
+	if *r.controladdr != "" {
+		go func() {
+			h := &control.Handler{
+				Writer:   w,
+				Limiter:  w.Limiter,
+				Snapshot: snapshot,
+				Stop:     stop,
+				Stopped:  p.ctx.Done(),
+			}
+			log.Fatalf("Error serving the control API on %s: %v\n", *r.controladdr, http.ListenAndServe(*r.controladdr, h))
+		}()
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		w.Run(p.cpoints)
+		close(finished)
+	}()
+
+	drained := true
+	select {
+	case <-finished:
+	case <-p.ctx.Done():
+		fmt.Fprintf(p.messages, "\nStopping, waiting up to %v for the batches already read to be written...\n", *r.shutdowntimeout)
+		select {
+		case <-finished:
+		case <-time.After(*r.shutdowntimeout):
+			drained = false
+		}
+	}
+
+	// undrained writers may still be using the output
+	if drained && p.flush != nil {
+		if err := p.flush(); err != nil {
+			log.Fatalf("Error flushing the output: %v\n", err)
+		}
+	}
+
+	// only shards with every point written, or saved to -failures, are
+	// recorded, so a batch that wasn't drained or was lost makes its shard
+	// be read again on the next run
+	if p.checkpointfile != "" {
+		var target func(db, rp string) (string, string)
+		if p.transform != nil {
+			target = p.transform.Map
+		}
+		for _, sh := range p.tracker.Settled(target) {
+			p.done[sh.Key()] = true
+		}
+		if err := checkpoint.Write(p.checkpointfile, p.done); err != nil {
+			log.Fatalf("Error writing checkpoint %s: %v\n", p.checkpointfile, err)
+		}
+	}
+
+	s := snapshot()
+	fmt.Fprintf(p.messages, "\n%d shards done, points read %d written %d failed %d, %d write retries, in %v\n",
+		s.ShardsDone, s.PointsRead, s.PointsWritten, s.PointsFailed, s.Retries, s.Elapsed.Truncate(time.Second))
+	if p.transform != nil {
+		p.transform.Report(p.messages)
+	}
+
+	switch {
+	case !drained:
+		fmt.Fprintf(p.messages, "%s stopped before the batches already read were written\n", p.name)
+		os.Exit(exitundrained)
+	case p.ctx.Err() != nil:
+		fmt.Fprintf(p.messages, "%s stopped\n", p.name)
+		os.Exit(exitstopped)
+	default:
+		fmt.Fprintf(p.messages, "%s completed!\n", p.name)
+	}
+}
+
+// interrupted calls stop on the first SIGINT or SIGTERM and exits right away
+// on the second one.
+func interrupted(stop func()) {
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	sig := <-c
+	log.Printf("Received %v, stopping. Send it again to exit right away\n", sig)
+	stop()
+	<-c
+	os.Exit(exitundrained)
+}
@@ -0,0 +1,328 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/vladlopes/influxdb-migrate/database"
+)
+
+// Tracker counts what was done out of the totals found by a Source Scan. Its
+// methods can be called on a nil Tracker.
+type Tracker struct {
+	mu    sync.Mutex
+	start time.Time
+
+	series    map[string]int
+	dbshards  map[string]int
+	databases int
+	shards    int
+	total     int
+
+	databasesdone int
+	shardsdone    int
+	seriesdone    int
+	read          int64
+	written       int64
+	failed        int64
+	bytes         int64
+	retries       int64
+	counts        map[RetentionPolicy]*Counts
+	current       map[string]database.Shard
+	done          []database.Shard
+}
+
+// RetentionPolicy identifies a retention policy of a database.
+type RetentionPolicy struct {
+	Database string
+	Name     string
+}
+
+// Counts are the points of a retention policy read, written and failed, and
+// the failed ones saved to be replayed.
+type Counts struct {
+	Read    int64
+	Written int64
+	Failed  int64
+	Saved   int64
+}
+
+// NewTracker returns a tracker whose totals are the shards given, which may be
+// none when there was no scan.
+func NewTracker(shards []database.Shard) *Tracker {
+	t := &Tracker{
+		start:    time.Now(),
+		series:   make(map[string]int),
+		dbshards: make(map[string]int),
+		shards:   len(shards),
+		counts:   make(map[RetentionPolicy]*Counts),
+		current:  make(map[string]database.Shard),
+	}
+	for _, sh := range shards {
+		t.series[sh.Key()] = sh.Series
+		t.total += sh.Series
+		if t.dbshards[sh.Database] == 0 {
+			t.databases++
+		}
+		t.dbshards[sh.Database]++
+	}
+	return t
+}
+
+func (t *Tracker) countsof(db, rp string) *Counts {
+	k := RetentionPolicy{Database: db, Name: rp}
+	c, ok := t.counts[k]
+	if !ok {
+		c = &Counts{}
+		t.counts[k] = c
+	}
+	return c
+}
+
+// Read counts points of a retention policy sent by the readers.
+func (t *Tracker) Read(db, rp string, points int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.read += int64(points)
+	t.countsof(db, rp).Read += int64(points)
+	t.mu.Unlock()
+}
+
+// ShardStarted marks a shard as being read.
+func (t *Tracker) ShardStarted(sh database.Shard) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.current[sh.Key()] = sh
+	t.mu.Unlock()
+}
+
+// ShardDone counts a shard, its series and, when it was the last one, its
+// database as done.
+func (t *Tracker) ShardDone(sh database.Shard) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.current, sh.Key())
+	t.done = append(t.done, sh)
+	t.shardsdone++
+	t.seriesdone += t.series[sh.Key()]
+	t.dbshards[sh.Database]--
+	if t.dbshards[sh.Database] == 0 {
+		t.databasesdone++
+	}
+}
+
+// Written counts points of a retention policy written to the destination
+// and their estimated bytes.
+func (t *Tracker) Written(db, rp string, points, bytes int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.written += int64(points)
+	t.bytes += int64(bytes)
+	t.countsof(db, rp).Written += int64(points)
+	t.mu.Unlock()
+}
+
+// Failed counts points of a retention policy the destination didn't accept.
+func (t *Tracker) Failed(db, rp string, points int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.failed += int64(points)
+	t.countsof(db, rp).Failed += int64(points)
+	t.mu.Unlock()
+}
+
+// Saved counts failed points of a retention policy saved to be replayed.
+func (t *Tracker) Saved(db, rp string, points int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.countsof(db, rp).Saved += int64(points)
+	t.mu.Unlock()
+}
+
+// Retried counts a write sent again after failing.
+func (t *Tracker) Retried() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.retries++
+	t.mu.Unlock()
+}
+
+// Counts returns the counts of every retention policy seen so far.
+func (t *Tracker) Counts() map[RetentionPolicy]Counts {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ret := make(map[RetentionPolicy]Counts, len(t.counts))
+	for k, c := range t.counts {
+		ret[k] = *c
+	}
+	return ret
+}
+
+// Current returns the shards being read.
+func (t *Tracker) Current() []database.Shard {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var ret []database.Shard
+	for _, sh := range t.current {
+		ret = append(ret, sh)
+	}
+	return ret
+}
+
+// Settled returns the shards completely read whose points were all written,
+// or saved to be replayed when they failed. The points are counted by the
+// retention policy they are written to, not by shard, so a point still
+// waiting for a writer or lost keeps every shard written to its retention
+// policy out. target returns the retention policy the points of a shard are
+// counted in, when they are written to another one.
+func (t *Tracker) Settled(target func(db, rp string) (string, string)) []database.Shard {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var ret []database.Shard
+	for _, sh := range t.done {
+		db, rp := sh.Database, sh.RetentionPolicy
+		if target != nil {
+			db, rp = target(db, rp)
+		}
+		c := t.countsof(db, rp)
+		if c.Written+c.Failed >= c.Read && c.Saved >= c.Failed {
+			ret = append(ret, sh)
+		}
+	}
+	return ret
+}
+
+// Snapshot is the progress at a moment. The fields after ETA are filled by
+// whoever reports it.
+type Snapshot struct {
+	Time            time.Time     `json:"time"`
+	Elapsed         time.Duration `json:"elapsed"`
+	Databases       int           `json:"databases"`
+	DatabasesDone   int           `json:"databases_done"`
+	Shards          int           `json:"shards"`
+	ShardsDone      int           `json:"shards_done"`
+	Series          int           `json:"series"`
+	SeriesDone      int           `json:"series_done"`
+	PointsRead      int64         `json:"points_read"`
+	PointsWritten   int64         `json:"points_written"`
+	PointsFailed    int64         `json:"points_failed"`
+	BytesSent       int64         `json:"bytes_sent"`
+	Retries         int64         `json:"retries"`
+	PointsPerSecond float64       `json:"points_per_second"`
+	// ETA is -1 while there is nothing done to estimate it from.
+	ETA time.Duration `json:"eta"`
+
+	PointsPerWrite int           `json:"points_per_write"`
+	LatencyP50     time.Duration `json:"latency_p50"`
+	LatencyP90     time.Duration `json:"latency_p90"`
+	LatencyP99     time.Duration `json:"latency_p99"`
+	MemoryUsed     int64         `json:"memory_used,omitempty"`
+	MemoryMax      int64         `json:"memory_max,omitempty"`
+}
+
+// Snapshot returns the current counters. The ETA is estimated from the
+// series already done, or from the shards when they have no series.
+func (t *Tracker) Snapshot() Snapshot {
+	if t == nil {
+		return Snapshot{Time: time.Now(), ETA: -1}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	s := Snapshot{
+		Time:          now,
+		Elapsed:       now.Sub(t.start),
+		Databases:     t.databases,
+		DatabasesDone: t.databasesdone,
+		Shards:        t.shards,
+		ShardsDone:    t.shardsdone,
+		Series:        t.total,
+		SeriesDone:    t.seriesdone,
+		PointsRead:    t.read,
+		PointsWritten: t.written,
+		PointsFailed:  t.failed,
+		BytesSent:     t.bytes,
+		Retries:       t.retries,
+		ETA:           -1,
+	}
+	done := float64(0)
+	switch {
+	case t.total > 0:
+		done = float64(t.seriesdone) / float64(t.total)
+	case t.shards > 0:
+		done = float64(t.shardsdone) / float64(t.shards)
+	}
+	if done > 0 {
+		s.ETA = time.Duration(float64(s.Elapsed) * (1 - done) / done)
+	}
+	return s
+}
+
+// Report writes a progress line, or a JSON object per line when asjson,
+// every interval. The throughput is the one of the last interval.
+func Report(out io.Writer, interval time.Duration, asjson bool, snapshot func() Snapshot) {
+	every(interval, snapshot, func(s Snapshot) {
+		if asjson {
+			b, _ := json.Marshal(s)
+			fmt.Fprintf(out, "%s\n", b)
+		} else {
+			fmt.Fprintf(out, "%s\n", s)
+		}
+	})
+}
+
+// every calls fn with a snapshot every interval, filling its throughput
+// from the previous one.
+func every(interval time.Duration, snapshot func() Snapshot, fn func(s Snapshot)) {
+	last := snapshot()
+	for range time.Tick(interval) {
+		s := snapshot()
+		if secs := s.Time.Sub(last.Time).Seconds(); secs > 0 {
+			s.PointsPerSecond = float64(s.PointsWritten-last.PointsWritten) / secs
+		}
+		last = s
+		fn(s)
+	}
+}
+
+func (s Snapshot) String() string {
+	eta := "unknown"
+	if s.ETA >= 0 {
+		eta = s.ETA.Truncate(time.Second).String()
+	}
+	ret := fmt.Sprintf("Progress: databases %d/%d, shards %d/%d, points read %d written %d failed %d, %.0f points/s, ETA %s, %d points per write, latency p50 %v p90 %v p99 %v",
+		s.DatabasesDone, s.Databases, s.ShardsDone, s.Shards,
+		s.PointsRead, s.PointsWritten, s.PointsFailed, s.PointsPerSecond, eta,
+		s.PointsPerWrite, s.LatencyP50, s.LatencyP90, s.LatencyP99)
+	if s.MemoryMax > 0 {
+		ret += fmt.Sprintf(", memory in use %d of %d bytes", s.MemoryUsed, s.MemoryMax)
+	}
+	return ret
+}
@@ -0,0 +1,118 @@
+package progress
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/vladlopes/influxdb-migrate/database"
+)
+
+func TestSettled(t *testing.T) {
+	a1 := database.Shard{Database: "a", RetentionPolicy: "rp", ID: "1"}
+	a2 := database.Shard{Database: "a", RetentionPolicy: "rp", ID: "2"}
+	b3 := database.Shard{Database: "b", RetentionPolicy: "rp", ID: "3"}
+	tests := []struct {
+		name   string
+		counts func(tr *Tracker)
+		target func(db, rp string) (string, string)
+		want   []string
+	}{
+		{
+			name: "all written",
+			counts: func(tr *Tracker) {
+				tr.Read("a", "rp", 10)
+				tr.Written("a", "rp", 10, 100)
+				tr.Read("b", "rp", 5)
+				tr.Written("b", "rp", 5, 50)
+			},
+			want: []string{"a/rp/1", "a/rp/2", "b/rp/3"},
+		},
+		{
+			name: "points waiting for a writer",
+			counts: func(tr *Tracker) {
+				tr.Read("a", "rp", 10)
+				tr.Written("a", "rp", 9, 90)
+				tr.Read("b", "rp", 5)
+				tr.Written("b", "rp", 5, 50)
+			},
+			want: []string{"b/rp/3"},
+		},
+		{
+			name: "failed not saved",
+			counts: func(tr *Tracker) {
+				tr.Read("a", "rp", 10)
+				tr.Written("a", "rp", 8, 80)
+				tr.Failed("a", "rp", 2)
+			},
+			want: []string{"b/rp/3"},
+		},
+		{
+			name: "failed and saved",
+			counts: func(tr *Tracker) {
+				tr.Read("a", "rp", 10)
+				tr.Written("a", "rp", 8, 80)
+				tr.Failed("a", "rp", 2)
+				tr.Saved("a", "rp", 2)
+			},
+			want: []string{"a/rp/1", "a/rp/2", "b/rp/3"},
+		},
+		{
+			name: "counted in the target",
+			counts: func(tr *Tracker) {
+				tr.Read("new", "rp", 10)
+				tr.Written("new", "rp", 9, 90)
+			},
+			target: func(db, rp string) (string, string) {
+				if db == "a" {
+					return "new", rp
+				}
+				return db, rp
+			},
+			want: []string{"b/rp/3"},
+		},
+	}
+	for _, tt := range tests {
+		tr := NewTracker([]database.Shard{a1, a2, b3})
+		for _, sh := range []database.Shard{a1, a2, b3} {
+			tr.ShardStarted(sh)
+			tr.ShardDone(sh)
+		}
+		tt.counts(tr)
+		var got []string
+		for _, sh := range tr.Settled(tt.target) {
+			got = append(got, sh.Key())
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Settled = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSettledNotDone(t *testing.T) {
+	sh := database.Shard{Database: "a", RetentionPolicy: "rp", ID: "1"}
+	tr := NewTracker([]database.Shard{sh})
+	tr.ShardStarted(sh)
+	if settled := tr.Settled(nil); len(settled) != 0 {
+		t.Errorf("Settled = %v while the shard is being read", settled)
+	}
+}
+
+func TestNilTracker(t *testing.T) {
+	var tr *Tracker
+	sh := database.Shard{Database: "a", RetentionPolicy: "rp", ID: "1"}
+	tr.Read("a", "rp", 1)
+	tr.ShardStarted(sh)
+	tr.ShardDone(sh)
+	tr.Written("a", "rp", 1, 1)
+	tr.Failed("a", "rp", 1)
+	tr.Saved("a", "rp", 1)
+	tr.Retried()
+	if tr.Counts() != nil || tr.Current() != nil || tr.Settled(nil) != nil {
+		t.Errorf("a nil Tracker returned counts or shards")
+	}
+	if s := tr.Snapshot(); s.ETA != -1 || s.Time.IsZero() {
+		t.Errorf("Snapshot of a nil Tracker = %+v", s)
+	}
+}
@@ -0,0 +1,55 @@
+package progress
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// Publish writes a migration point with the progress to the database every
+// interval, creating the database first, so the migration can be watched
+// next to the data being migrated. The datapath tag tells migrations apart.
+func Publish(c *client.Client, db, datapath string, interval time.Duration, t *Tracker, snapshot func() Snapshot) {
+	if _, err := c.Query(client.Query{Command: fmt.Sprintf("create database %s", db)}); err != nil {
+		log.Printf("Error creating database %s for the migration stats: %v\n", db, err)
+	}
+
+	every(interval, snapshot, func(s Snapshot) {
+		var current []string
+		for _, sh := range t.Current() {
+			current = append(current, sh.Key())
+		}
+		fields := map[string]interface{}{
+			"databases_done":    int64(s.DatabasesDone),
+			"shards":            int64(s.Shards),
+			"shards_done":       int64(s.ShardsDone),
+			"points_read":       s.PointsRead,
+			"points_written":    s.PointsWritten,
+			"points_failed":     s.PointsFailed,
+			"points_per_second": s.PointsPerSecond,
+			"bytes_sent":        s.BytesSent,
+			"retries":           s.Retries,
+			"points_per_write":  int64(s.PointsPerWrite),
+			"latency_p99":       s.LatencyP99.Seconds(),
+			"current_shards":    strings.Join(current, ","),
+		}
+		if s.ETA >= 0 {
+			fields["eta"] = s.ETA.Seconds()
+		}
+		bp := client.BatchPoints{
+			Database: db,
+			Points: []client.Point{{
+				Measurement: "migration",
+				Tags:        map[string]string{"datapath": datapath},
+				Fields:      fields,
+				Time:        s.Time,
+			}},
+		}
+		if _, err := c.Write(bp); err != nil {
+			log.Printf("Error writing migration stats to %s: %v\n", db, err)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package progress
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/client"
+	"github.com/vladlopes/influxdb-migrate/database"
+)
+
+func TestPublish(t *testing.T) {
+	queries := make(chan string, 10)
+	writes := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/query":
+			queries <- r.URL.Query().Get("q")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"results":[{}]}`))
+		case "/write":
+			b, _ := ioutil.ReadAll(r.Body)
+			writes <- r.URL.Query().Get("db") + " " + string(b)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	c, err := client.NewClient(client.Config{URL: *u})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := NewTracker(nil)
+	tr.ShardStarted(database.Shard{Database: "db", RetentionPolicy: "rp", ID: "1"})
+	// the publisher never returns, it is left running until the test ends
+	go Publish(c, "_migration", "/data", 10*time.Millisecond, tr, func() Snapshot {
+		return Snapshot{Time: time.Now(), PointsWritten: 42, ETA: -1}
+	})
+
+	select {
+	case q := <-queries:
+		if q != "create database _migration" {
+			t.Errorf("query = %q, want create database _migration", q)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("the database wasn't created")
+	}
+	select {
+	case w := <-writes:
+		for _, want := range []string{"_migration migration,datapath=/data ", "points_written=42", `current_shards="db/rp/1"`} {
+			if !strings.Contains(w, want) {
+				t.Errorf("write %q doesn't have %q", w, want)
+			}
+		}
+		if strings.Contains(w, "eta=") {
+			t.Errorf("write %q has an eta while it is unknown", w)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no stats written")
+	}
+}
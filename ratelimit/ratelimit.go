@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket limiting points and bytes per second, shared by
+// every writer. Each rate holds up to one second of tokens. A zero rate
+// doesn't limit.
+type Limiter struct {
+	mu     sync.Mutex
+	points bucket
+	bytes  bucket
+}
+
+type bucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens accumulated since the last call, up to one second
+// worth of them.
+func (b *bucket) refill(now time.Time) {
+	if b.rate <= 0 {
+		return
+	}
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+}
+
+// take removes n tokens and returns how long to wait until the bucket isn't
+// in debt anymore. Requests bigger than the bucket are let through in debt so
+// big batches are slowed down instead of blocked forever.
+func (b *bucket) take(n float64) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func New(pointspersecond, bytespersecond float64) *Limiter {
+	l := &Limiter{}
+	l.SetRates(pointspersecond, bytespersecond)
+	return l
+}
+
+// SetRates changes the rates. It can be called while writers are waiting.
+func (l *Limiter) SetRates(pointspersecond, bytespersecond float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.points = bucket{rate: pointspersecond, tokens: pointspersecond, last: now}
+	l.bytes = bucket{rate: bytespersecond, tokens: bytespersecond, last: now}
+}
+
+// Rates returns the current rates.
+func (l *Limiter) Rates() (pointspersecond, bytespersecond float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.points.rate, l.bytes.rate
+}
+
+// Wait blocks until points and bytes can be written without going over the
+// rates.
+func (l *Limiter) Wait(points, bytes int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.points.refill(now)
+	l.bytes.refill(now)
+	wait := l.points.take(float64(points))
+	if w := l.bytes.take(float64(bytes)); w > wait {
+		wait = w
+	}
+	l.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// ReadFile reads the rates from a file with pointspersecond and
+// bytespersecond lines like:
+//
+//	pointspersecond=5000
+//	bytespersecond=1000000
+//
+// A missing line means no limit.
+func ReadFile(path string) (pointspersecond, bytespersecond float64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return 0, 0, fmt.Errorf("Invalid line %q in %s", line, path)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil || v < 0 {
+			return 0, 0, fmt.Errorf("Invalid rate %q in %s", kv[1], path)
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "pointspersecond":
+			pointspersecond = v
+		case "bytespersecond":
+			bytespersecond = v
+		default:
+			return 0, 0, fmt.Errorf("Unknown rate %q in %s", kv[0], path)
+		}
+	}
+	return pointspersecond, bytespersecond, s.Err()
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRates(t *testing.T) {
+	l := New(100, 2000)
+	if p, b := l.Rates(); p != 100 || b != 2000 {
+		t.Errorf("Rates = %v, %v, want 100, 2000", p, b)
+	}
+	l.SetRates(0, 50)
+	if p, b := l.Rates(); p != 0 || b != 50 {
+		t.Errorf("Rates = %v, %v, want 0, 50", p, b)
+	}
+}
+
+// waited returns how long Wait(points, bytes) blocked.
+func waited(l *Limiter, points, bytes int) time.Duration {
+	start := time.Now()
+	l.Wait(points, bytes)
+	return time.Since(start)
+}
+
+func TestWait(t *testing.T) {
+	tests := []struct {
+		name          string
+		points, bytes float64
+		waitp, waitb  int
+		min, max      time.Duration
+	}{
+		{name: "no limit", waitp: 1000000, waitb: 1000000, max: 50 * time.Millisecond},
+		{name: "within the bucket", points: 100, waitp: 100, max: 50 * time.Millisecond},
+		{name: "points in debt", points: 100, waitp: 120, min: 150 * time.Millisecond, max: time.Second},
+		{name: "bytes in debt", points: 1000, bytes: 100, waitp: 1, waitb: 120, min: 150 * time.Millisecond, max: time.Second},
+	}
+	for _, tt := range tests {
+		l := New(tt.points, tt.bytes)
+		if d := waited(l, tt.waitp, tt.waitb); d < tt.min || d > tt.max {
+			t.Errorf("%s: waited %v, want between %v and %v", tt.name, d, tt.min, tt.max)
+		}
+	}
+}
+
+func TestWaitAfterSetRates(t *testing.T) {
+	l := New(10, 0)
+	l.Wait(10, 0)
+	// the bucket is empty but new rates start full
+	l.SetRates(100, 0)
+	if d := waited(l, 100, 0); d > 50*time.Millisecond {
+		t.Errorf("waited %v after SetRates, want no wait", d)
+	}
+}
+
+func TestNilWait(t *testing.T) {
+	var l *Limiter
+	if d := waited(l, 1<<30, 1<<30); d > 50*time.Millisecond {
+		t.Errorf("nil Limiter waited %v", d)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		points, bytes float64
+		err           bool
+	}{
+		{name: "both", content: "pointspersecond=5000\nbytespersecond=1000000\n", points: 5000, bytes: 1000000},
+		{name: "missing line", content: "# points only\n\n pointspersecond = 10 \n", points: 10},
+		{name: "empty", content: ""},
+		{name: "no equals", content: "pointspersecond 10\n", err: true},
+		{name: "not a number", content: "pointspersecond=fast\n", err: true},
+		{name: "negative", content: "bytespersecond=-1\n", err: true},
+		{name: "unknown rate", content: "linespersecond=1\n", err: true},
+	}
+	dir, err := ioutil.TempDir("", "ratelimit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rates")
+	for _, tt := range tests {
+		if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		p, b, err := ReadFile(path)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.err)
+			continue
+		}
+		if !tt.err && (p != tt.points || b != tt.bytes) {
+			t.Errorf("%s: ReadFile = %v, %v, want %v, %v", tt.name, p, b, tt.points, tt.bytes)
+		}
+	}
+	if _, _, err := ReadFile(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("no error reading a missing file")
+	}
+}
@@ -0,0 +1,143 @@
+package transform
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// Convert moves tags into fields and fields into tags. Each rule is
+// optionally scoped like the tag rules:
+//
+//	tofield request_id
+//	web/requests: totag status
+//
+// A tag becomes a string field and a field becomes a tag with its value
+// formatted like in line protocol. A field isn't moved when it is the only
+// one of the point, as a point needs a field.
+type Convert struct {
+	rules ruleset
+}
+
+type convertrule struct {
+	points int64
+	kept   int64
+
+	scope
+	rule   string
+	action string
+	key    string
+}
+
+// NewConvert parses the rules.
+func NewConvert(rules []string) (*Convert, error) {
+	c := &Convert{}
+	for _, rule := range rules {
+		r := &convertrule{rule: rule}
+		var words []string
+		r.scope, words = parsescope(rule)
+		if len(words) != 2 || (words[0] != "tofield" && words[0] != "totag") {
+			return nil, fmt.Errorf("Invalid conversion %s. Must be like [db/measurement:] tofield key or totag key", rule)
+		}
+		r.action, r.key = words[0], words[1]
+		c.rules.add(r)
+	}
+	return c, nil
+}
+
+// Point moves the tags and fields of a point of db. Both are copied before
+// being changed, as the readers may share them between points.
+func (c *Convert) Point(db string, p *client.Point) {
+	rules := c.rules.in(db, p.Measurement)
+	if len(rules) == 0 {
+		return
+	}
+	tags, fields := p.Tags, p.Fields
+	copied := false
+	for _, sr := range rules {
+		r := sr.(*convertrule)
+		switch r.action {
+		case "tofield":
+			if _, ok := tags[r.key]; !ok {
+				continue
+			}
+		case "totag":
+			if _, ok := fields[r.key]; !ok {
+				continue
+			}
+			if len(fields) == 1 {
+				atomic.AddInt64(&r.kept, 1)
+				continue
+			}
+		}
+		if !copied {
+			tags, fields = copytags(tags), copyfields(fields)
+			copied = true
+		}
+		if r.action == "tofield" {
+			fields[r.key] = tags[r.key]
+			delete(tags, r.key)
+		} else {
+			tags[r.key] = format(fields[r.key])
+			delete(fields, r.key)
+		}
+		atomic.AddInt64(&r.points, 1)
+	}
+	p.Tags, p.Fields = tags, fields
+}
+
+// Field tells whether a field of a measurement of db is still written as a
+// field, assuming it isn't the only one of its points.
+func (c *Convert) Field(db, m, name string) bool {
+	for _, sr := range c.rules.in(db, m) {
+		r := sr.(*convertrule)
+		if r.action == "totag" && r.key == name {
+			return false
+		}
+	}
+	return true
+}
+
+func copytags(tags map[string]string) map[string]string {
+	c := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		c[k] = v
+	}
+	return c
+}
+
+func copyfields(fields map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		c[k] = v
+	}
+	return c
+}
+
+// format returns a field value as a tag value.
+func format(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Report writes the points changed by each rule and, for the fields, the
+// points where they were kept as the only field.
+func (c *Convert) Report(w io.Writer) {
+	for _, sr := range c.rules.all() {
+		r := sr.(*convertrule)
+		fmt.Fprintf(w, "Convert %s: %d points", r.rule, atomic.LoadInt64(&r.points))
+		if kept := atomic.LoadInt64(&r.kept); kept > 0 {
+			fmt.Fprintf(w, ", kept as the only field in %d", kept)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
@@ -0,0 +1,133 @@
+package transform
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name       string
+		rules      []string
+		m          string
+		tags       map[string]string
+		fields     map[string]interface{}
+		wanttags   map[string]string
+		wantfields map[string]interface{}
+	}{
+		{
+			name:       "tofield",
+			rules:      []string{"tofield request_id"},
+			tags:       map[string]string{"request_id": "42", "host": "a"},
+			fields:     map[string]interface{}{"value": 1.0},
+			wanttags:   map[string]string{"host": "a"},
+			wantfields: map[string]interface{}{"value": 1.0, "request_id": "42"},
+		},
+		{
+			name:       "totag",
+			rules:      []string{"totag status"},
+			tags:       map[string]string{"host": "a"},
+			fields:     map[string]interface{}{"value": 1.0, "status": int64(200)},
+			wanttags:   map[string]string{"host": "a", "status": "200"},
+			wantfields: map[string]interface{}{"value": 1.0},
+		},
+		{
+			name:       "totag float",
+			rules:      []string{"totag ratio"},
+			fields:     map[string]interface{}{"value": 1.0, "ratio": 0.5},
+			wanttags:   map[string]string{"ratio": "0.5"},
+			wantfields: map[string]interface{}{"value": 1.0},
+		},
+		{
+			name:       "only field kept",
+			rules:      []string{"totag status"},
+			fields:     map[string]interface{}{"status": "ok"},
+			wantfields: map[string]interface{}{"status": "ok"},
+		},
+		{
+			name:       "missing",
+			rules:      []string{"tofield request_id", "totag status"},
+			tags:       map[string]string{"host": "a"},
+			fields:     map[string]interface{}{"value": 1.0},
+			wanttags:   map[string]string{"host": "a"},
+			wantfields: map[string]interface{}{"value": 1.0},
+		},
+		{
+			name:       "scoped",
+			rules:      []string{"db/requests: totag status"},
+			m:          "requests",
+			fields:     map[string]interface{}{"value": 1.0, "status": true},
+			wanttags:   map[string]string{"status": "true"},
+			wantfields: map[string]interface{}{"value": 1.0},
+		},
+		{
+			name:       "out of scope",
+			rules:      []string{"db/requests: totag status"},
+			m:          "other",
+			fields:     map[string]interface{}{"value": 1.0, "status": true},
+			wantfields: map[string]interface{}{"value": 1.0, "status": true},
+		},
+	}
+	for _, tt := range tests {
+		c, err := NewConvert(tt.rules)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if tt.m == "" {
+			tt.m = "m"
+		}
+		origtags, origfields := copytags(tt.tags), copyfields(tt.fields)
+		p := &client.Point{Measurement: tt.m, Tags: tt.tags, Fields: tt.fields}
+		c.Point("db", p)
+		if len(p.Tags) != 0 || len(tt.wanttags) != 0 {
+			if !reflect.DeepEqual(p.Tags, tt.wanttags) {
+				t.Errorf("%s: tags = %v, want %v", tt.name, p.Tags, tt.wanttags)
+			}
+		}
+		if !reflect.DeepEqual(p.Fields, tt.wantfields) {
+			t.Errorf("%s: fields = %v, want %v", tt.name, p.Fields, tt.wantfields)
+		}
+		if len(tt.tags) != len(origtags) || !reflect.DeepEqual(tt.fields, origfields) {
+			t.Errorf("%s: the tags or fields shared with other points changed", tt.name)
+		}
+	}
+}
+
+func TestConvertField(t *testing.T) {
+	c, err := NewConvert([]string{"web/requests: totag status", "tofield host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Field("web", "requests", "status") {
+		t.Errorf("status of web/requests still a field")
+	}
+	if !c.Field("web", "other", "status") || !c.Field("web", "requests", "value") {
+		t.Errorf("a field out of the rules isn't a field anymore")
+	}
+}
+
+func TestConvertReport(t *testing.T) {
+	c, err := NewConvert([]string{"totag status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Point("db", &client.Point{Measurement: "m", Fields: map[string]interface{}{"status": "ok", "value": 1.0}})
+	c.Point("db", &client.Point{Measurement: "m", Fields: map[string]interface{}{"status": "ok"}})
+	b := &bytes.Buffer{}
+	c.Report(b)
+	if want := "Convert totag status: 1 points, kept as the only field in 1\n"; b.String() != want {
+		t.Errorf("Report = %q, want %q", b.String(), want)
+	}
+}
+
+func TestConvertInvalid(t *testing.T) {
+	for _, rule := range []string{"tofield", "totag a b", "upper status", "db: tofield"} {
+		if _, err := NewConvert([]string{rule}); err == nil {
+			t.Errorf("no error for conversion %q", rule)
+		}
+	}
+}
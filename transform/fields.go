@@ -0,0 +1,278 @@
+package transform
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// maxsamples is the number of values that couldn't be coerced kept by each
+// rule to be reported.
+const maxsamples = 5
+
+// Fields drops, renames and coerces the fields of the points. Each rule is
+// optionally scoped like the tag rules:
+//
+//	drop debug_*
+//	keep value,count
+//	rename val=value
+//	cpu: coerce load float
+//	coerce active integer
+//
+// where the fields dropped and kept may use * to match any name. A coerce
+// to float converts integers, booleans and numeric strings, and one to
+// integer converts booleans, integer strings and floats without decimals.
+// Values that can't be coerced are dropped and reported. Points left without
+// fields are dropped. Every rule in scope is applied in the order given.
+type Fields struct {
+	dropped int64
+
+	rules ruleset
+}
+
+type fieldrule struct {
+	points int64
+	failed int64
+
+	scope
+	rule     string
+	action   string
+	key, to  string
+	names    []*regexp.Regexp
+	samplemu sync.Mutex
+	samples  []string
+}
+
+// NewFields parses the rules.
+func NewFields(rules []string) (*Fields, error) {
+	f := &Fields{}
+	for _, rule := range rules {
+		r, err := parsefieldrule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid field rule %s: %v", rule, err)
+		}
+		f.rules.add(r)
+	}
+	return f, nil
+}
+
+func parsefieldrule(rule string) (*fieldrule, error) {
+	r := &fieldrule{rule: rule}
+	var words []string
+	r.scope, words = parsescope(rule)
+	if len(words) < 2 {
+		return nil, fmt.Errorf("must be like [db/measurement:] action arguments")
+	}
+	r.action = words[0]
+	switch r.action {
+	case "drop", "keep":
+		if len(words) != 2 {
+			return nil, fmt.Errorf("%s takes a list of fields separated by commas", r.action)
+		}
+		for _, name := range strings.Split(words[1], ",") {
+			r.names = append(r.names, glob(name))
+		}
+	case "rename":
+		parts := strings.SplitN(words[1], "=", 2)
+		if len(words) != 2 || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("rename takes one old=new")
+		}
+		r.key, r.to = parts[0], parts[1]
+	case "coerce":
+		if len(words) != 3 || (words[2] != "float" && words[2] != "integer") {
+			return nil, fmt.Errorf("coerce takes a field and float or integer")
+		}
+		r.key, r.to = words[1], words[2]
+	default:
+		return nil, fmt.Errorf("unknown action %s. Valids: drop, keep, rename, coerce", r.action)
+	}
+	return r, nil
+}
+
+// Coerce adds a rule, before the ones given, coercing a field of a
+// measurement of a database to a type.
+func (f *Fields) Coerce(db, m, field, to string) {
+	r := &fieldrule{
+		scope: scope{
+			db: regexp.MustCompile("^" + regexp.QuoteMeta(db) + "$"),
+			m:  regexp.MustCompile("^" + regexp.QuoteMeta(m) + "$"),
+		},
+		rule:   fmt.Sprintf("%s/%s: coerce %s %s", db, m, field, to),
+		action: "coerce",
+		key:    field,
+		to:     to,
+	}
+	f.rules.prepend(r)
+}
+
+// Point changes the fields of a point of db, returning false when it has
+// none left. The fields are copied before being changed.
+func (f *Fields) Point(db string, p *client.Point) bool {
+	rules := f.rules.in(db, p.Measurement)
+	if len(rules) == 0 {
+		return true
+	}
+	fields := copyfields(p.Fields)
+	for _, sr := range rules {
+		r := sr.(*fieldrule)
+		if r.apply(fields) {
+			atomic.AddInt64(&r.points, 1)
+		}
+	}
+	p.Fields = fields
+	if len(fields) == 0 {
+		atomic.AddInt64(&f.dropped, 1)
+		return false
+	}
+	return true
+}
+
+// Field returns the name and type a field of a measurement of db is written
+// with, false when it is dropped. Coercions are expected to succeed.
+func (f *Fields) Field(db, m, name, typ string) (string, string, bool) {
+	for _, sr := range f.rules.in(db, m) {
+		r := sr.(*fieldrule)
+		switch r.action {
+		case "drop", "keep":
+			if r.matches(name) == (r.action == "drop") {
+				return "", "", false
+			}
+		case "rename":
+			if name == r.key {
+				name = r.to
+			}
+		case "coerce":
+			if name == r.key {
+				typ = r.to
+			}
+		}
+	}
+	return name, typ, true
+}
+
+// apply changes the fields, telling whether anything changed.
+func (r *fieldrule) apply(fields map[string]interface{}) bool {
+	switch r.action {
+	case "drop", "keep":
+		changed := false
+		for k := range fields {
+			if r.matches(k) == (r.action == "drop") {
+				delete(fields, k)
+				changed = true
+			}
+		}
+		return changed
+	case "rename":
+		v, ok := fields[r.key]
+		if !ok {
+			return false
+		}
+		delete(fields, r.key)
+		fields[r.to] = v
+		return true
+	case "coerce":
+		v, ok := fields[r.key]
+		if !ok {
+			return false
+		}
+		c, ok := coerce(v, r.to)
+		if !ok {
+			delete(fields, r.key)
+			r.fail(v)
+			return true
+		}
+		fields[r.key] = c
+		return c != v
+	}
+	return false
+}
+
+func (r *fieldrule) matches(name string) bool {
+	for _, re := range r.names {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// fail counts a value that couldn't be coerced, keeping the first different
+// ones.
+func (r *fieldrule) fail(v interface{}) {
+	atomic.AddInt64(&r.failed, 1)
+	sample := fmt.Sprintf("%#v", v)
+	r.samplemu.Lock()
+	defer r.samplemu.Unlock()
+	if len(r.samples) >= maxsamples {
+		return
+	}
+	for _, s := range r.samples {
+		if s == sample {
+			return
+		}
+	}
+	r.samples = append(r.samples, sample)
+}
+
+// coerce converts a value to float or integer.
+func coerce(v interface{}, to string) (interface{}, bool) {
+	if to == "float" {
+		switch v := v.(type) {
+		case float64:
+			return v, true
+		case int64:
+			return float64(v), true
+		case bool:
+			if v {
+				return float64(1), true
+			}
+			return float64(0), true
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			return f, err == nil
+		}
+		return nil, false
+	}
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v != float64(int64(v)) {
+			return nil, false
+		}
+		return int64(v), true
+	case bool:
+		if v {
+			return int64(1), true
+		}
+		return int64(0), true
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return i, err == nil
+	}
+	return nil, false
+}
+
+// Report writes the points changed by each rule and the values that couldn't
+// be coerced.
+func (f *Fields) Report(w io.Writer) {
+	for _, sr := range f.rules.all() {
+		r := sr.(*fieldrule)
+		fmt.Fprintf(w, "Field %s: %d points", r.rule, atomic.LoadInt64(&r.points))
+		if failed := atomic.LoadInt64(&r.failed); failed > 0 {
+			r.samplemu.Lock()
+			fmt.Fprintf(w, ", %d values couldn't be coerced and were dropped, like %s", failed, strings.Join(r.samples, ", "))
+			r.samplemu.Unlock()
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	if dropped := atomic.LoadInt64(&f.dropped); dropped > 0 {
+		fmt.Fprintf(w, "Field rules left %d points without fields, which were dropped\n", dropped)
+	}
+}
@@ -0,0 +1,153 @@
+package transform
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+func TestCoerce(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		to   string
+		want interface{}
+		ok   bool
+	}{
+		{v: 1.5, to: "float", want: 1.5, ok: true},
+		{v: int64(3), to: "float", want: 3.0, ok: true},
+		{v: true, to: "float", want: 1.0, ok: true},
+		{v: false, to: "float", want: 0.0, ok: true},
+		{v: " 2.5 ", to: "float", want: 2.5, ok: true},
+		{v: "fast", to: "float"},
+		{v: int64(3), to: "integer", want: int64(3), ok: true},
+		{v: 4.0, to: "integer", want: int64(4), ok: true},
+		{v: 4.5, to: "integer"},
+		{v: true, to: "integer", want: int64(1), ok: true},
+		{v: "12", to: "integer", want: int64(12), ok: true},
+		{v: "1.0", to: "integer"},
+	}
+	for _, tt := range tests {
+		got, ok := coerce(tt.v, tt.to)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("coerce(%#v, %s) = %#v, %v, want %#v, %v", tt.v, tt.to, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []string
+		m      string
+		fields map[string]interface{}
+		want   map[string]interface{}
+		kept   bool
+	}{
+		{name: "drop", rules: []string{"drop debug_*"}, fields: map[string]interface{}{"debug_a": 1.0, "value": 1.0}, want: map[string]interface{}{"value": 1.0}, kept: true},
+		{name: "keep", rules: []string{"keep value,count"}, fields: map[string]interface{}{"value": 1.0, "count": int64(1), "other": "x"}, want: map[string]interface{}{"value": 1.0, "count": int64(1)}, kept: true},
+		{name: "rename", rules: []string{"rename val=value"}, fields: map[string]interface{}{"val": 1.0}, want: map[string]interface{}{"value": 1.0}, kept: true},
+		{name: "coerce", rules: []string{"coerce load float"}, fields: map[string]interface{}{"load": int64(2)}, want: map[string]interface{}{"load": 2.0}, kept: true},
+		{name: "coerce fails", rules: []string{"coerce load float"}, fields: map[string]interface{}{"load": "high", "value": 1.0}, want: map[string]interface{}{"value": 1.0}, kept: true},
+		{name: "nothing left", rules: []string{"drop *"}, fields: map[string]interface{}{"value": 1.0}, want: map[string]interface{}{}},
+		{name: "in order", rules: []string{"rename val=value", "coerce value integer"}, fields: map[string]interface{}{"val": 1.0}, want: map[string]interface{}{"value": int64(1)}, kept: true},
+		{name: "scoped", rules: []string{"db/cpu: drop debug"}, m: "cpu", fields: map[string]interface{}{"debug": 1.0, "value": 1.0}, want: map[string]interface{}{"value": 1.0}, kept: true},
+		{name: "out of scope", rules: []string{"db/cpu: drop debug"}, m: "mem", fields: map[string]interface{}{"debug": 1.0, "value": 1.0}, want: map[string]interface{}{"debug": 1.0, "value": 1.0}, kept: true},
+	}
+	for _, tt := range tests {
+		f, err := NewFields(tt.rules)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if tt.m == "" {
+			tt.m = "m"
+		}
+		orig := copyfields(tt.fields)
+		p := &client.Point{Measurement: tt.m, Fields: tt.fields}
+		if kept := f.Point("db", p); kept != tt.kept {
+			t.Errorf("%s: Point = %v, want %v", tt.name, kept, tt.kept)
+		}
+		if !reflect.DeepEqual(p.Fields, tt.want) {
+			t.Errorf("%s: fields = %v, want %v", tt.name, p.Fields, tt.want)
+		}
+		if !reflect.DeepEqual(tt.fields, orig) {
+			t.Errorf("%s: the fields shared with other points changed to %v", tt.name, tt.fields)
+		}
+	}
+}
+
+func TestFieldsField(t *testing.T) {
+	f, err := NewFields([]string{"drop debug_*", "rename val=value", "cpu: coerce value float"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		db, m, name, typ string
+		toname, totyp    string
+		ok               bool
+	}{
+		{db: "db", m: "m", name: "debug_a", typ: "float"},
+		{db: "db", m: "m", name: "val", typ: "integer", toname: "value", totyp: "integer", ok: true},
+		{db: "cpu", m: "m", name: "val", typ: "integer", toname: "value", totyp: "float", ok: true},
+		{db: "cpu", m: "m", name: "other", typ: "string", toname: "other", totyp: "string", ok: true},
+	}
+	for _, tt := range tests {
+		name, typ, ok := f.Field(tt.db, tt.m, tt.name, tt.typ)
+		if name != tt.toname || typ != tt.totyp || ok != tt.ok {
+			t.Errorf("Field(%s, %s, %s, %s) = %s, %s, %v, want %s, %s, %v", tt.db, tt.m, tt.name, tt.typ, name, typ, ok, tt.toname, tt.totyp, tt.ok)
+		}
+	}
+}
+
+func TestFieldsCoerceRescopes(t *testing.T) {
+	f, err := NewFields([]string{"rename load=load1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the rules in scope of db/cpu are cached before the coercion is added
+	p := &client.Point{Measurement: "cpu", Fields: map[string]interface{}{"load": int64(1)}}
+	f.Point("db", p)
+
+	f.Coerce("db", "cpu", "load", "float")
+	p = &client.Point{Measurement: "cpu", Fields: map[string]interface{}{"load": int64(1)}}
+	f.Point("db", p)
+	if want := map[string]interface{}{"load1": 1.0}; !reflect.DeepEqual(p.Fields, want) {
+		t.Errorf("fields = %v, want %v coerced before being renamed", p.Fields, want)
+	}
+
+	// other measurements are out of the coercion scope
+	p = &client.Point{Measurement: "cpu2", Fields: map[string]interface{}{"load": int64(1)}}
+	f.Point("db", p)
+	if want := map[string]interface{}{"load1": int64(1)}; !reflect.DeepEqual(p.Fields, want) {
+		t.Errorf("fields = %v, want %v", p.Fields, want)
+	}
+}
+
+func TestFieldsReport(t *testing.T) {
+	f, err := NewFields([]string{"coerce load integer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []interface{}{"high", "high", 1.5, int64(1)} {
+		f.Point("db", &client.Point{Measurement: "m", Fields: map[string]interface{}{"load": v}})
+	}
+	b := &bytes.Buffer{}
+	f.Report(b)
+	want := "Field coerce load integer: 3 points, 3 values couldn't be coerced and were dropped, like \"high\", 1.5\n" +
+		"Field rules left 3 points without fields, which were dropped\n"
+	if b.String() != want {
+		t.Errorf("Report = %q, want %q", b.String(), want)
+	}
+}
+
+func TestFieldsInvalid(t *testing.T) {
+	for _, rule := range []string{"drop", "drop a b", "rename val", "rename =value", "coerce load", "coerce load string", "upper value"} {
+		_, err := NewFields([]string{rule})
+		if err == nil || !strings.HasPrefix(err.Error(), "Invalid field rule") {
+			t.Errorf("error = %v for field rule %q", err, rule)
+		}
+	}
+}
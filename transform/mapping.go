@@ -0,0 +1,99 @@
+package transform
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Mapping maps the databases and retention policies of the old version to
+// the ones written to. Each rule is like
+//
+//	metrics=metrics_legacy
+//	old_*=merged
+//	telegraf/default=telegraf/two_weeks
+//	*/default=*/autogen
+//
+// where the left side is a database, or a database and a retention policy,
+// and * matches any name. A * in the right side is replaced by what the *
+// in the same part of the left side matched, or by the whole name when that
+// part has no *. A database without a retention
+// policy on the right side keeps its own. The first rule matching is used.
+type Mapping struct {
+	rules []maprule
+}
+
+type maprule struct {
+	db, rp     *regexp.Regexp
+	todb, torp string
+}
+
+// NewMapping parses the rules, adding the prefix and suffix to every database
+// no rule matches, like a last rule *=prefix*suffix.
+func NewMapping(rules []string, prefix, suffix string) (*Mapping, error) {
+	m := &Mapping{}
+	for _, r := range rules {
+		parts := strings.SplitN(r, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("Invalid mapping %s. Must be like olddb=newdb or olddb/oldrp=newdb/newrp", r)
+		}
+		from, to := split(parts[0]), split(parts[1])
+		rule := maprule{db: glob(from[0]), todb: to[0]}
+		if len(from) == 2 {
+			rule.rp = glob(from[1])
+		}
+		if len(to) == 2 {
+			rule.torp = to[1]
+		}
+		m.rules = append(m.rules, rule)
+	}
+	if prefix != "" || suffix != "" {
+		m.rules = append(m.rules, maprule{db: glob("*"), todb: prefix + "*" + suffix})
+	}
+	return m, nil
+}
+
+func split(s string) []string {
+	return strings.SplitN(s, "/", 2)
+}
+
+// glob returns a regular expression matching the whole name with * as a
+// capture group.
+func glob(s string) *regexp.Regexp {
+	return regexp.MustCompile("^" + strings.Replace(regexp.QuoteMeta(s), `\*`, "(.*)", -1) + "$")
+}
+
+// Map returns the database and retention policy the points of db and rp are
+// written to.
+func (m *Mapping) Map(db, rp string) (string, string) {
+	for _, r := range m.rules {
+		dbm := r.db.FindStringSubmatch(db)
+		if dbm == nil {
+			continue
+		}
+		var rpm []string
+		if r.rp != nil {
+			if rpm = r.rp.FindStringSubmatch(rp); rpm == nil {
+				continue
+			}
+		}
+		todb, torp := expand(r.todb, dbm), rp
+		if r.torp != "" {
+			if rpm == nil {
+				rpm = []string{rp}
+			}
+			torp = expand(r.torp, rpm)
+		}
+		return todb, torp
+	}
+	return db, rp
+}
+
+// expand replaces the * in to with what the * of the rule matched, or with
+// the whole name.
+func expand(to string, match []string) string {
+	if len(match) > 1 {
+		return strings.Replace(to, "*", match[1], -1)
+	}
+	return strings.Replace(to, "*", match[0], -1)
+}
@@ -0,0 +1,47 @@
+package transform
+
+import "testing"
+
+func TestMapping(t *testing.T) {
+	tests := []struct {
+		name           string
+		rules          []string
+		prefix, suffix string
+		db, rp         string
+		todb, torp     string
+	}{
+		{name: "database", rules: []string{"metrics=metrics_legacy"}, db: "metrics", rp: "default", todb: "metrics_legacy", torp: "default"},
+		{name: "no match", rules: []string{"metrics=metrics_legacy"}, db: "other", rp: "default", todb: "other", torp: "default"},
+		{name: "glob", rules: []string{"old_*=merged"}, db: "old_web", rp: "rp", todb: "merged", torp: "rp"},
+		{name: "glob is whole name", rules: []string{"old_*=merged"}, db: "very_old_web", rp: "rp", todb: "very_old_web", torp: "rp"},
+		{name: "glob replaced", rules: []string{"old_*=new_*"}, db: "old_web", rp: "rp", todb: "new_web", torp: "rp"},
+		{name: "star without glob", rules: []string{"metrics=*_v2"}, db: "metrics", rp: "rp", todb: "metrics_v2", torp: "rp"},
+		{name: "retention policy", rules: []string{"telegraf/default=telegraf/two_weeks"}, db: "telegraf", rp: "default", todb: "telegraf", torp: "two_weeks"},
+		{name: "other retention policy", rules: []string{"telegraf/default=telegraf/two_weeks"}, db: "telegraf", rp: "monthly", todb: "telegraf", torp: "monthly"},
+		{name: "any database", rules: []string{"*/default=*/autogen"}, db: "web", rp: "default", todb: "web", torp: "autogen"},
+		{name: "retention policy star", rules: []string{"web=new/*_old"}, db: "web", rp: "default", todb: "new", torp: "default_old"},
+		{name: "first match", rules: []string{"web=first", "w*=second"}, db: "web", rp: "rp", todb: "first", torp: "rp"},
+		{name: "later match", rules: []string{"web=first", "w*=second"}, db: "www", rp: "rp", todb: "second", torp: "rp"},
+		{name: "prefix and suffix", prefix: "old_", suffix: "_v1", db: "web", rp: "rp", todb: "old_web_v1", torp: "rp"},
+		{name: "rules before prefix", rules: []string{"web=kept"}, prefix: "old_", db: "web", rp: "rp", todb: "kept", torp: "rp"},
+		{name: "metacharacters", rules: []string{"a.b=c"}, db: "axb", rp: "rp", todb: "axb", torp: "rp"},
+	}
+	for _, tt := range tests {
+		m, err := NewMapping(tt.rules, tt.prefix, tt.suffix)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if db, rp := m.Map(tt.db, tt.rp); db != tt.todb || rp != tt.torp {
+			t.Errorf("%s: Map(%s, %s) = %s, %s, want %s, %s", tt.name, tt.db, tt.rp, db, rp, tt.todb, tt.torp)
+		}
+	}
+}
+
+func TestMappingInvalid(t *testing.T) {
+	for _, rule := range []string{"metrics", "=new", "old=", ""} {
+		if _, err := NewMapping([]string{rule}, "", ""); err == nil {
+			t.Errorf("no error for mapping %q", rule)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package transform
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Rename rewrites the measurement names with regular expressions. Each rule
+// is like
+//
+//	cpu_load_short=cpu
+//	(.*)_load_(.*)=load_$2
+//
+// where the expression, everything before the last =, must match the whole
+// name, and the replacement may use its groups as $1 or ${name}. The first
+// rule matching is used.
+type Rename struct {
+	rules []*renamerule
+
+	mu sync.RWMutex
+	// names caches the rule matching each measurement, nil for none.
+	names map[string]*renamerule
+}
+
+type renamerule struct {
+	points int64
+
+	rule string
+	re   *regexp.Regexp
+	to   string
+}
+
+// NewRename parses the rules.
+func NewRename(rules []string) (*Rename, error) {
+	r := &Rename{names: make(map[string]*renamerule)}
+	for _, rule := range rules {
+		i := strings.LastIndex(rule, "=")
+		if i <= 0 || i == len(rule)-1 {
+			return nil, fmt.Errorf("Invalid rename %s. Must be like regex=replacement", rule)
+		}
+		if _, err := regexp.Compile(rule[:i]); err != nil {
+			return nil, fmt.Errorf("Invalid rename %s: %v", rule, err)
+		}
+		re := regexp.MustCompile("^(?:" + rule[:i] + ")$")
+		r.rules = append(r.rules, &renamerule{rule: rule, re: re, to: rule[i+1:]})
+	}
+	return r, nil
+}
+
+// Validate checks that every rule matches one of the measurements and that
+// no measurement is renamed to an empty name.
+func (r *Rename) Validate(measurements []string) error {
+	used := make(map[*renamerule]bool)
+	for _, m := range measurements {
+		if rule := r.rule(m); rule != nil {
+			used[rule] = true
+			if rule.re.ReplaceAllString(m, rule.to) == "" {
+				return fmt.Errorf("Invalid rename %s: renames %s to an empty name", rule.rule, m)
+			}
+		}
+	}
+	for _, rule := range r.rules {
+		if !used[rule] {
+			return fmt.Errorf("Invalid rename %s: matches no measurement", rule.rule)
+		}
+	}
+	return nil
+}
+
+// Measurement returns the new name of a measurement, counting the point for
+// the rule used.
+func (r *Rename) Measurement(name string) string {
+	rule := r.rule(name)
+	if rule == nil {
+		return name
+	}
+	atomic.AddInt64(&rule.points, 1)
+	return rule.re.ReplaceAllString(name, rule.to)
+}
+
+// Name returns the new name of a measurement without counting any point.
+func (r *Rename) Name(name string) string {
+	rule := r.rule(name)
+	if rule == nil {
+		return name
+	}
+	return rule.re.ReplaceAllString(name, rule.to)
+}
+
+// rule returns the first rule matching the name.
+func (r *Rename) rule(name string) *renamerule {
+	r.mu.RLock()
+	rule, ok := r.names[name]
+	r.mu.RUnlock()
+	if ok {
+		return rule
+	}
+	for _, rr := range r.rules {
+		if rr.re.MatchString(name) {
+			rule = rr
+			break
+		}
+	}
+	r.mu.Lock()
+	r.names[name] = rule
+	r.mu.Unlock()
+	return rule
+}
+
+// Report writes the points renamed by each rule.
+func (r *Rename) Report(w io.Writer) {
+	for _, rule := range r.rules {
+		fmt.Fprintf(w, "Rename %s: %d points\n", rule.rule, atomic.LoadInt64(&rule.points))
+	}
+}
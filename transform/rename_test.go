@@ -0,0 +1,89 @@
+package transform
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRename(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		in    string
+		out   string
+	}{
+		{name: "literal", rules: []string{"cpu_load_short=cpu"}, in: "cpu_load_short", out: "cpu"},
+		{name: "whole name", rules: []string{"cpu=processor"}, in: "cpu_load", out: "cpu_load"},
+		{name: "groups", rules: []string{"(.*)_load_(.*)=load_$2"}, in: "cpu_load_short", out: "load_short"},
+		{name: "named group", rules: []string{"(?P<host>[a-z]+)_mem=mem_${host}"}, in: "web_mem", out: "mem_web"},
+		{name: "last equals", rules: []string{"a=b=c"}, in: "a=b", out: "c"},
+		{name: "alternation anchored", rules: []string{"cpu|mem=sys"}, in: "cpu2", out: "cpu2"},
+		{name: "first match", rules: []string{"c.*=first", "cpu=second"}, in: "cpu", out: "first"},
+		{name: "no match", rules: []string{"cpu=processor"}, in: "mem", out: "mem"},
+	}
+	for _, tt := range tests {
+		r, err := NewRename(tt.rules)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if out := r.Name(tt.in); out != tt.out {
+			t.Errorf("%s: Name(%s) = %s, want %s", tt.name, tt.in, out, tt.out)
+		}
+		// twice, the second time from the cache
+		if out := r.Measurement(tt.in); out != tt.out {
+			t.Errorf("%s: Measurement(%s) = %s, want %s", tt.name, tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestRenameInvalid(t *testing.T) {
+	for _, rule := range []string{"cpu", "=cpu", "cpu=", "cpu(=x"} {
+		if _, err := NewRename([]string{rule}); err == nil {
+			t.Errorf("no error for rename %q", rule)
+		}
+	}
+}
+
+func TestRenameValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		err   string
+	}{
+		{name: "all used", rules: []string{"cpu=processor", "m(.*)=memory$1"}},
+		{name: "unused", rules: []string{"cpu=processor", "disk=storage"}, err: "disk=storage: matches no measurement"},
+		{name: "shadowed", rules: []string{"c.*=c", "cpu=processor"}, err: "cpu=processor: matches no measurement"},
+		{name: "empty name", rules: []string{"mem(.*)=$1"}, err: "renames mem to an empty name"},
+	}
+	for _, tt := range tests {
+		r, err := NewRename(tt.rules)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		err = r.Validate([]string{"cpu", "mem"})
+		if tt.err == "" && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: error = %v, want %s", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestRenameReport(t *testing.T) {
+	r, err := NewRename([]string{"cpu=processor", "mem=memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Measurement("cpu")
+	r.Measurement("cpu")
+	r.Name("mem")
+	b := &bytes.Buffer{}
+	r.Report(b)
+	if want := "Rename cpu=processor: 2 points\nRename mem=memory: 0 points\n"; b.String() != want {
+		t.Errorf("Report = %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,233 @@
+package transform
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// MaxString is the longest string field the new versions accept, in bytes.
+const MaxString = 64 * 1024
+
+// Reasons a value is sanitized, in the order they are reported.
+const (
+	// NaN is a float field that is NaN or infinite.
+	NaN = "nan"
+	// LongString is a string field longer than MaxString.
+	LongString = "longstring"
+	// Newline is a measurement, tag or field name, or a tag value, with a
+	// newline.
+	Newline = "newline"
+	// Empty is a point without fields, checked last.
+	Empty = "empty"
+)
+
+var reasons = []string{NaN, LongString, Newline, Empty}
+
+// actions are the valid actions of each reason, the first one the default.
+var actions = map[string][]string{
+	NaN:        {"dropfield", "droppoint", "sentinel", "keep"},
+	LongString: {"truncate", "dropfield", "droppoint", "keep"},
+	Newline:    {"replace", "droppoint", "sentinel", "keep"},
+	Empty:      {"droppoint", "keep"},
+}
+
+// Sanitize changes the values the new version rejects, so one of them
+// doesn't make the whole batch fail. Each reason has an action, changed by
+// rules like
+//
+//	nan=sentinel:-1
+//	longstring=dropfield
+//	newline=sentinel:_
+//
+// where dropfield drops the value, droppoint the point, truncate cuts the
+// string to MaxString, replace changes each newline to a space, sentinel:x
+// writes x instead of the value or of each newline, and keep leaves it as
+// it is.
+type Sanitize struct {
+	counts [4]int64
+
+	action map[string]string
+	// rules are the actions as given, with their sentinels, to report them.
+	rules   map[string]string
+	nan     float64
+	newline string
+}
+
+// NewSanitize parses the rules, keeping the default action of the reasons
+// without one.
+func NewSanitize(rules []string) (*Sanitize, error) {
+	s := &Sanitize{action: make(map[string]string), rules: make(map[string]string), newline: " "}
+	for _, r := range reasons {
+		s.action[r] = actions[r][0]
+		s.rules[r] = actions[r][0]
+	}
+	for _, rule := range rules {
+		parts := strings.SplitN(rule, "=", 2)
+		valid, ok := actions[parts[0]]
+		if len(parts) != 2 || !ok {
+			return nil, fmt.Errorf("Invalid sanitize rule %s. Must be like reason=action with a reason of %s", rule, strings.Join(reasons, ", "))
+		}
+		reason, action := parts[0], parts[1]
+		var arg string
+		hasarg := false
+		if i := strings.Index(action, ":"); i >= 0 {
+			action, arg, hasarg = action[:i], action[i+1:], true
+		}
+		found := false
+		for _, a := range valid {
+			found = found || a == action
+		}
+		// only sentinel takes a value
+		if !found || (action == "sentinel") != hasarg {
+			return nil, fmt.Errorf("Invalid sanitize rule %s. Valid actions of %s: %s, with sentinel:value", rule, reason, strings.Join(valid, ", "))
+		}
+		if action == "sentinel" && reason == NaN {
+			f, err := strconv.ParseFloat(arg, 64)
+			if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+				return nil, fmt.Errorf("Invalid sanitize rule %s: the sentinel must be a finite number", rule)
+			}
+			s.nan = f
+		}
+		if action == "sentinel" && reason == Newline {
+			s.newline = arg
+		}
+		s.action[reason] = action
+		s.rules[reason] = parts[1]
+	}
+	return s, nil
+}
+
+// count adds one to the counter of the reason.
+func (s *Sanitize) count(reason string) {
+	for i, r := range reasons {
+		if r == reason {
+			atomic.AddInt64(&s.counts[i], 1)
+		}
+	}
+}
+
+// Point sanitizes a point, returning false to drop it. The tags and fields
+// are copied before being changed.
+func (s *Sanitize) Point(p *client.Point) bool {
+	if strings.Contains(p.Measurement, "\n") && s.action[Newline] != "keep" {
+		s.count(Newline)
+		if s.action[Newline] == "droppoint" {
+			return false
+		}
+		p.Measurement = s.replace(p.Measurement)
+	}
+
+	tagscopied := false
+	for k, v := range p.Tags {
+		if !strings.Contains(k, "\n") && !strings.Contains(v, "\n") || s.action[Newline] == "keep" {
+			continue
+		}
+		s.count(Newline)
+		if s.action[Newline] == "droppoint" {
+			return false
+		}
+		if !tagscopied {
+			p.Tags = copytags(p.Tags)
+			tagscopied = true
+		}
+		delete(p.Tags, k)
+		p.Tags[s.replace(k)] = s.replace(v)
+	}
+
+	fields := p.Fields
+	fieldscopied := false
+	change := func(k string, v interface{}, drop bool) {
+		if !fieldscopied {
+			fields = copyfields(fields)
+			fieldscopied = true
+		}
+		delete(fields, k)
+		if !drop {
+			fields[k] = v
+		}
+	}
+	for k, v := range p.Fields {
+		if strings.Contains(k, "\n") && s.action[Newline] != "keep" {
+			s.count(Newline)
+			if s.action[Newline] == "droppoint" {
+				return false
+			}
+			change(k, nil, true)
+			k = s.replace(k)
+			change(k, v, false)
+		}
+		switch v := v.(type) {
+		case float64:
+			if (!math.IsNaN(v) && !math.IsInf(v, 0)) || s.action[NaN] == "keep" {
+				continue
+			}
+			s.count(NaN)
+			switch s.action[NaN] {
+			case "droppoint":
+				return false
+			case "dropfield":
+				change(k, nil, true)
+			case "sentinel":
+				change(k, s.nan, false)
+			}
+		case string:
+			if len(v) <= MaxString || s.action[LongString] == "keep" {
+				continue
+			}
+			s.count(LongString)
+			switch s.action[LongString] {
+			case "droppoint":
+				return false
+			case "dropfield":
+				change(k, nil, true)
+			case "truncate":
+				change(k, truncate(v, MaxString), false)
+			}
+		}
+	}
+	p.Fields = fields
+
+	if len(p.Fields) == 0 && s.action[Empty] != "keep" {
+		s.count(Empty)
+		return false
+	}
+	return true
+}
+
+// replace changes every newline of a name.
+func (s *Sanitize) replace(name string) string {
+	if s.action[Newline] == "keep" {
+		return name
+	}
+	return strings.Replace(name, "\n", s.newline, -1)
+}
+
+// truncate cuts a string to at most n bytes without splitting a character.
+func truncate(v string, n int) string {
+	for n > 0 && !utf8.RuneStart(v[n]) {
+		n--
+	}
+	return v[:n]
+}
+
+// Report writes the values sanitized for each reason.
+func (s *Sanitize) Report(w io.Writer) {
+	for i, r := range reasons {
+		n := atomic.LoadInt64(&s.counts[i])
+		if n == 0 {
+			continue
+		}
+		what := "values"
+		if r == Empty {
+			what = "points"
+		}
+		fmt.Fprintf(w, "Sanitize %s=%s: %d %s\n", r, s.rules[r], n, what)
+	}
+}
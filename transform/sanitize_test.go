@@ -0,0 +1,158 @@
+package transform
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+func TestSanitize(t *testing.T) {
+	long := strings.Repeat("x", MaxString+1)
+	tests := []struct {
+		name  string
+		rules []string
+		point client.Point
+		want  client.Point
+		kept  bool
+	}{
+		{
+			name:  "clean",
+			point: client.Point{Measurement: "m", Tags: map[string]string{"host": "a"}, Fields: map[string]interface{}{"value": 1.0}},
+			want:  client.Point{Measurement: "m", Tags: map[string]string{"host": "a"}, Fields: map[string]interface{}{"value": 1.0}},
+			kept:  true,
+		},
+		{
+			name:  "nan dropfield",
+			point: client.Point{Measurement: "m", Fields: map[string]interface{}{"value": math.NaN(), "other": 1.0}},
+			want:  client.Point{Measurement: "m", Fields: map[string]interface{}{"other": 1.0}},
+			kept:  true,
+		},
+		{
+			name:  "nan dropfield leaves it empty",
+			point: client.Point{Measurement: "m", Fields: map[string]interface{}{"value": math.Inf(1)}},
+		},
+		{
+			name:  "nan droppoint",
+			rules: []string{"nan=droppoint"},
+			point: client.Point{Measurement: "m", Fields: map[string]interface{}{"value": math.NaN(), "other": 1.0}},
+		},
+		{
+			name:  "nan sentinel",
+			rules: []string{"nan=sentinel:-1"},
+			point: client.Point{Measurement: "m", Fields: map[string]interface{}{"value": math.Inf(-1)}},
+			want:  client.Point{Measurement: "m", Fields: map[string]interface{}{"value": -1.0}},
+			kept:  true,
+		},
+		{
+			name:  "long string truncate",
+			point: client.Point{Measurement: "m", Fields: map[string]interface{}{"value": long}},
+			want:  client.Point{Measurement: "m", Fields: map[string]interface{}{"value": long[:MaxString]}},
+			kept:  true,
+		},
+		{
+			name:  "long string keep",
+			rules: []string{"longstring=keep"},
+			point: client.Point{Measurement: "m", Fields: map[string]interface{}{"value": long}},
+			want:  client.Point{Measurement: "m", Fields: map[string]interface{}{"value": long}},
+			kept:  true,
+		},
+		{
+			name:  "newline replace",
+			point: client.Point{Measurement: "a\nb", Tags: map[string]string{"k\n": "v\nw"}, Fields: map[string]interface{}{"f\ng": 1.0}},
+			want:  client.Point{Measurement: "a b", Tags: map[string]string{"k ": "v w"}, Fields: map[string]interface{}{"f g": 1.0}},
+			kept:  true,
+		},
+		{
+			name:  "newline sentinel",
+			rules: []string{"newline=sentinel:_"},
+			point: client.Point{Measurement: "m", Tags: map[string]string{"host": "a\nb"}, Fields: map[string]interface{}{"value": 1.0}},
+			want:  client.Point{Measurement: "m", Tags: map[string]string{"host": "a_b"}, Fields: map[string]interface{}{"value": 1.0}},
+			kept:  true,
+		},
+		{
+			name:  "newline droppoint",
+			rules: []string{"newline=droppoint"},
+			point: client.Point{Measurement: "m", Fields: map[string]interface{}{"a\nb": 1.0}},
+		},
+		{
+			name:  "empty keep",
+			rules: []string{"empty=keep"},
+			point: client.Point{Measurement: "m", Fields: map[string]interface{}{}},
+			want:  client.Point{Measurement: "m", Fields: map[string]interface{}{}},
+			kept:  true,
+		},
+	}
+	for _, tt := range tests {
+		s, err := NewSanitize(tt.rules)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		p := tt.point
+		origtags, origfields := copytags(p.Tags), copyfields(p.Fields)
+		kept := s.Point(&p)
+		if kept != tt.kept {
+			t.Errorf("%s: Point = %v, want %v", tt.name, kept, tt.kept)
+			continue
+		}
+		if kept && (p.Measurement != tt.want.Measurement || !reflect.DeepEqual(p.Tags, tt.want.Tags) || !reflect.DeepEqual(p.Fields, tt.want.Fields)) {
+			t.Errorf("%s: point = %v, want %v", tt.name, p, tt.want)
+		}
+		if len(tt.point.Tags) != len(origtags) || len(tt.point.Fields) != len(origfields) {
+			t.Errorf("%s: the tags or fields shared with other points changed", tt.name)
+		}
+		for k, v := range origtags {
+			if tt.point.Tags[k] != v {
+				t.Errorf("%s: the tags shared with other points changed", tt.name)
+			}
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	// é takes two bytes, cutting at 4 would split the second one
+	if got := truncate("aéé", 4); got != "aé" {
+		t.Errorf("truncate = %q, want %q", got, "aé")
+	}
+	s := strings.Repeat("é", MaxString)
+	if got := truncate(s, MaxString); len(got) != MaxString || !utf8.ValidString(got) {
+		t.Errorf("truncate to %d bytes, valid %v, want %d", len(got), utf8.ValidString(got), MaxString)
+	}
+}
+
+func TestSanitizeInvalid(t *testing.T) {
+	for _, rule := range []string{
+		"nan",
+		"infinity=dropfield",
+		"nan=truncate",
+		"nan=sentinel",
+		"nan=sentinel:x",
+		"nan=sentinel:NaN",
+		"nan=dropfield:1",
+		"empty=sentinel:1",
+		"longstring=replace",
+	} {
+		if _, err := NewSanitize([]string{rule}); err == nil {
+			t.Errorf("no error for sanitize rule %q", rule)
+		}
+	}
+}
+
+func TestSanitizeReport(t *testing.T) {
+	s, err := NewSanitize([]string{"nan=sentinel:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Point(&client.Point{Measurement: "m", Fields: map[string]interface{}{"a": math.NaN(), "b": math.NaN()}})
+	s.Point(&client.Point{Measurement: "m", Fields: map[string]interface{}{}})
+	b := &bytes.Buffer{}
+	s.Report(b)
+	if want := "Sanitize nan=sentinel:0: 2 values\nSanitize empty=droppoint: 1 points\n"; b.String() != want {
+		t.Errorf("Report = %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,92 @@
+package transform
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// scope is the databases and measurements of the old version a rule applies
+// to.
+type scope struct {
+	db, m *regexp.Regexp
+}
+
+func (s scope) inscope(db, m string) bool {
+	return s.db.MatchString(db) && s.m.MatchString(m)
+}
+
+// parsescope returns the databases and measurements a rule is scoped to,
+// every one when the rule has no scope, and the words after the scope.
+func parsescope(rule string) (scope, []string) {
+	sc := scope{db: glob("*"), m: glob("*")}
+	s := strings.TrimSpace(rule)
+	if i := strings.Index(s, ":"); i >= 0 && !strings.ContainsAny(s[:i], " =") {
+		parts := split(s[:i])
+		sc.db = glob(parts[0])
+		if len(parts) == 2 {
+			sc.m = glob(parts[1])
+		}
+		s = s[i+1:]
+	}
+	return sc, strings.Fields(s)
+}
+
+// scopedrule is a rule with a scope, like the rules embedding scope.
+type scopedrule interface {
+	inscope(db, m string) bool
+}
+
+// ruleset holds the scoped rules of a transform in the order they are
+// applied, caching the ones in scope of each database and measurement.
+type ruleset struct {
+	mu     sync.RWMutex
+	rules  []scopedrule
+	scoped map[[2]string][]scopedrule
+}
+
+// add appends a rule.
+func (s *ruleset) add(r scopedrule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(s.rules, r)
+	s.scoped = nil
+}
+
+// prepend adds a rule before the others.
+func (s *ruleset) prepend(r scopedrule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append([]scopedrule{r}, s.rules...)
+	s.scoped = nil
+}
+
+// all returns every rule.
+func (s *ruleset) all() []scopedrule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rules
+}
+
+// in returns the rules in scope of the database and measurement.
+func (s *ruleset) in(db, m string) []scopedrule {
+	k := [2]string{db, m}
+	s.mu.RLock()
+	rules, ok := s.scoped[k]
+	s.mu.RUnlock()
+	if ok {
+		return rules
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.rules {
+		if r.inscope(db, m) {
+			rules = append(rules, r)
+		}
+	}
+	if s.scoped == nil {
+		s.scoped = make(map[[2]string][]scopedrule)
+	}
+	s.scoped[k] = rules
+	return rules
+}
@@ -0,0 +1,200 @@
+package transform
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// Tags changes the tags of the points. Each rule is an action, optionally
+// scoped to the databases, or databases and measurements, of the old version
+// before a colon:
+//
+//	rename host=hostname
+//	drop request_*
+//	add migrated_from=0.9.2
+//	map region us-east=use1,us-west=usw2
+//	map region @regions.txt
+//	telegraf/cpu*: drop cpu
+//
+// where the scope and the keys dropped may use * to match any name, and the
+// file of a map has a old=new line per value. Every rule in scope is applied
+// in the order given.
+type Tags struct {
+	rules ruleset
+}
+
+type tagrule struct {
+	points int64
+
+	scope
+	rule       string
+	action     string
+	key, value string
+	drop       *regexp.Regexp
+	values     map[string]string
+}
+
+// NewTags parses the rules, reading the files of the maps.
+func NewTags(rules []string) (*Tags, error) {
+	t := &Tags{}
+	for _, rule := range rules {
+		r, err := parsetagrule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid tag rule %s: %v", rule, err)
+		}
+		t.rules.add(r)
+	}
+	return t, nil
+}
+
+func parsetagrule(rule string) (*tagrule, error) {
+	r := &tagrule{rule: rule}
+	var fields []string
+	r.scope, fields = parsescope(rule)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("must be like [db/measurement:] action arguments")
+	}
+	r.action = fields[0]
+	switch r.action {
+	case "rename", "add":
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s takes one key=value", r.action)
+		}
+		// the new version rejects or drops tags with empty values
+		parts := strings.SplitN(fields[1], "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("%s takes one key=value, neither empty", r.action)
+		}
+		r.key, r.value = parts[0], parts[1]
+	case "drop":
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("drop takes one key")
+		}
+		r.drop = glob(fields[1])
+	case "map":
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("map takes a key and old=new values or a @file")
+		}
+		r.key = fields[1]
+		var err error
+		if strings.HasPrefix(fields[2], "@") {
+			r.values, err = readvalues(fields[2][1:])
+		} else {
+			r.values, err = parsevalues(strings.Split(fields[2], ","))
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown action %s. Valids: rename, drop, add, map", r.action)
+	}
+	return r, nil
+}
+
+// readvalues reads a file with a old=new line per value, skipping empty
+// lines and the ones starting with #.
+func readvalues(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return parsevalues(lines)
+}
+
+func parsevalues(pairs []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid value %s. Must be like old=new, neither empty", pair)
+		}
+		values[parts[0]] = parts[1]
+	}
+	return values, nil
+}
+
+// Point changes the tags of a point of db. The tags are copied first, as the
+// readers share them between the points of a series.
+func (t *Tags) Point(db string, p *client.Point) {
+	rules := t.rules.in(db, p.Measurement)
+	if len(rules) == 0 {
+		return
+	}
+	tags := copytags(p.Tags)
+	for _, sr := range rules {
+		r := sr.(*tagrule)
+		if r.apply(tags) {
+			atomic.AddInt64(&r.points, 1)
+		}
+	}
+	p.Tags = tags
+}
+
+// apply changes the tags, telling whether anything changed.
+func (r *tagrule) apply(tags map[string]string) bool {
+	switch r.action {
+	case "rename":
+		v, ok := tags[r.key]
+		if !ok {
+			return false
+		}
+		delete(tags, r.key)
+		tags[r.value] = v
+		return true
+	case "drop":
+		changed := false
+		for k := range tags {
+			if r.drop.MatchString(k) {
+				delete(tags, k)
+				changed = true
+			}
+		}
+		return changed
+	case "add":
+		if v, ok := tags[r.key]; ok && v == r.value {
+			return false
+		}
+		tags[r.key] = r.value
+		return true
+	case "map":
+		old, ok := tags[r.key]
+		if !ok {
+			return false
+		}
+		v, ok := r.values[old]
+		if !ok {
+			return false
+		}
+		tags[r.key] = v
+		return true
+	}
+	return false
+}
+
+// Report writes the points changed by each rule.
+func (t *Tags) Report(w io.Writer) {
+	for _, sr := range t.rules.all() {
+		r := sr.(*tagrule)
+		fmt.Fprintf(w, "Tag %s: %d points\n", r.rule, atomic.LoadInt64(&r.points))
+	}
+}
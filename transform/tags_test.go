@@ -0,0 +1,101 @@
+package transform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+func TestTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		db    string
+		m     string
+		tags  map[string]string
+		want  map[string]string
+	}{
+		{name: "rename", rules: []string{"rename host=hostname"}, tags: map[string]string{"host": "a"}, want: map[string]string{"hostname": "a"}},
+		{name: "rename missing", rules: []string{"rename host=hostname"}, tags: map[string]string{"region": "a"}, want: map[string]string{"region": "a"}},
+		{name: "drop glob", rules: []string{"drop request_*"}, tags: map[string]string{"request_id": "1", "request_ip": "2", "host": "a"}, want: map[string]string{"host": "a"}},
+		{name: "add", rules: []string{"add migrated_from=0.9.2"}, tags: map[string]string{"host": "a"}, want: map[string]string{"host": "a", "migrated_from": "0.9.2"}},
+		{name: "add replaces", rules: []string{"add env=prod"}, tags: map[string]string{"env": "dev"}, want: map[string]string{"env": "prod"}},
+		{name: "map", rules: []string{"map region us-east=use1,us-west=usw2"}, tags: map[string]string{"region": "us-west"}, want: map[string]string{"region": "usw2"}},
+		{name: "map unknown value", rules: []string{"map region us-east=use1"}, tags: map[string]string{"region": "eu"}, want: map[string]string{"region": "eu"}},
+		{name: "in order", rules: []string{"rename host=hostname", "map hostname a=b"}, tags: map[string]string{"host": "a"}, want: map[string]string{"hostname": "b"}},
+		{name: "scoped database", rules: []string{"telegraf: drop cpu"}, db: "telegraf", m: "cpu", tags: map[string]string{"cpu": "0"}, want: map[string]string{}},
+		{name: "out of database scope", rules: []string{"telegraf: drop cpu"}, db: "other", m: "cpu", tags: map[string]string{"cpu": "0"}, want: map[string]string{"cpu": "0"}},
+		{name: "scoped measurement", rules: []string{"telegraf/cpu*: drop cpu"}, db: "telegraf", m: "cpu_total", tags: map[string]string{"cpu": "0"}, want: map[string]string{}},
+		{name: "out of measurement scope", rules: []string{"telegraf/cpu*: drop cpu"}, db: "telegraf", m: "mem", tags: map[string]string{"cpu": "0"}, want: map[string]string{"cpu": "0"}},
+		{name: "no tags", rules: []string{"add env=prod"}, want: map[string]string{"env": "prod"}},
+	}
+	for _, tt := range tests {
+		tr, err := NewTags(tt.rules)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if tt.db == "" {
+			tt.db, tt.m = "db", "m"
+		}
+		var orig map[string]string
+		if tt.tags != nil {
+			orig = copytags(tt.tags)
+		}
+		p := &client.Point{Measurement: tt.m, Tags: tt.tags}
+		tr.Point(tt.db, p)
+		if !reflect.DeepEqual(p.Tags, tt.want) {
+			t.Errorf("%s: tags = %v, want %v", tt.name, p.Tags, tt.want)
+		}
+		if !reflect.DeepEqual(tt.tags, orig) {
+			t.Errorf("%s: the tags shared with other points changed to %v", tt.name, tt.tags)
+		}
+	}
+}
+
+func TestTagsMapFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "regions.txt")
+	if err := ioutil.WriteFile(path, []byte("# regions\nus-east=use1\n\n us-west=usw2 \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := NewTags([]string{"map region @" + path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &client.Point{Measurement: "m", Tags: map[string]string{"region": "us-west"}}
+	tr.Point("db", p)
+	if p.Tags["region"] != "usw2" {
+		t.Errorf("region = %s, want usw2", p.Tags["region"])
+	}
+}
+
+func TestTagsInvalid(t *testing.T) {
+	for _, rule := range []string{
+		"rename",
+		"rename host",
+		"rename host=",
+		"rename =hostname",
+		"add env=",
+		"add =prod",
+		"add env=prod extra",
+		"drop",
+		"map region",
+		"map region us-east=",
+		"map region =use1",
+		"map region @/missing/file",
+		"upper host",
+	} {
+		if _, err := NewTags([]string{rule}); err == nil {
+			t.Errorf("no error for tag rule %q", rule)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package transform
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// precisions are the units the timestamps can be truncated to, with the
+// precision of the writes sending them.
+var precisions = map[string]struct {
+	unit      time.Duration
+	precision string
+}{
+	"s":  {time.Second, "s"},
+	"ms": {time.Millisecond, "ms"},
+	"us": {time.Microsecond, "u"},
+}
+
+// Time shifts the timestamps of the points by a fixed offset, like to make a
+// restore in a test environment look recent, and truncates them to a
+// precision, written with the points so the writes send less bytes.
+type Time struct {
+	shift     time.Duration
+	unit      time.Duration
+	precision string
+}
+
+// NewTime returns the transform shifting the timestamps by shift and
+// truncating them to precision, one of s, ms and us, or empty to keep them
+// in nanoseconds.
+func NewTime(shift time.Duration, precision string) (*Time, error) {
+	t := &Time{shift: shift}
+	if precision != "" {
+		p, ok := precisions[precision]
+		if !ok {
+			return nil, fmt.Errorf("Invalid precision %s. Valids: s, ms, us", precision)
+		}
+		t.unit, t.precision = p.unit, p.precision
+	}
+	return t, nil
+}
+
+// Point shifts and truncates the timestamp of a point.
+func (t *Time) Point(p *client.Point) {
+	p.Time = p.Time.Add(t.shift)
+	if t.unit > 0 {
+		p.Time = p.Time.Truncate(t.unit)
+		p.Precision = t.precision
+	}
+}
+
+// Precision returns the precision of the writes, empty for nanoseconds.
+func (t *Time) Precision() string {
+	return t.precision
+}
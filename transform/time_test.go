@@ -0,0 +1,50 @@
+package transform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+func TestTime(t *testing.T) {
+	at := time.Unix(1000, 123456789).UTC()
+	tests := []struct {
+		name      string
+		shift     time.Duration
+		precision string
+		want      time.Time
+		writes    string
+	}{
+		{name: "nothing", want: at},
+		{name: "shift", shift: time.Hour, want: at.Add(time.Hour)},
+		{name: "shift back", shift: -time.Hour, want: at.Add(-time.Hour)},
+		{name: "seconds", precision: "s", want: time.Unix(1000, 0).UTC(), writes: "s"},
+		{name: "milliseconds", precision: "ms", want: time.Unix(1000, 123000000).UTC(), writes: "ms"},
+		{name: "microseconds", precision: "us", want: time.Unix(1000, 123456000).UTC(), writes: "u"},
+		{name: "shift then truncate", shift: 900 * time.Millisecond, precision: "s", want: time.Unix(1001, 0).UTC(), writes: "s"},
+	}
+	for _, tt := range tests {
+		tr, err := NewTime(tt.shift, tt.precision)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		p := &client.Point{Measurement: "m", Time: at}
+		tr.Point(p)
+		if !p.Time.Equal(tt.want) {
+			t.Errorf("%s: time = %v, want %v", tt.name, p.Time, tt.want)
+		}
+		if p.Precision != tt.writes || tr.Precision() != tt.writes {
+			t.Errorf("%s: precision %q of the point, %q of the writes, want %q", tt.name, p.Precision, tr.Precision(), tt.writes)
+		}
+	}
+}
+
+func TestTimeInvalid(t *testing.T) {
+	for _, precision := range []string{"ns", "u", "m", "h"} {
+		if _, err := NewTime(0, precision); err == nil {
+			t.Errorf("no error for precision %q", precision)
+		}
+	}
+}
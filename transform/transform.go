@@ -0,0 +1,115 @@
+// Package transform changes the points read from the old version before they
+// are written. The counters reported by the transforms and their rules are
+// the first fields of their structs, so they are aligned for sync/atomic on
+// 32-bit platforms.
+package transform
+
+import (
+	"io"
+
+	"github.com/influxdb/influxdb/client"
+)
+
+// Chain is every transform asked for, each one applied in a fixed order. It
+// implements database.Transform.
+type Chain struct {
+	Mapping *Mapping
+	Convert *Convert
+	Fields  *Fields
+	Tags    *Tags
+	Rename  *Rename
+	Time    *Time
+	// Sanitize is applied last, to what the other transforms left.
+	Sanitize *Sanitize
+}
+
+// Map returns where the points of a retention policy of the old version are
+// written to.
+func (c *Chain) Map(db, rp string) (string, string) {
+	if c.Mapping == nil {
+		return db, rp
+	}
+	return c.Mapping.Map(db, rp)
+}
+
+// Point applies the transforms to a point of db and rp, the ones of the old
+// version, returning false to drop it.
+func (c *Chain) Point(db, rp string, p *client.Point) bool {
+	// the rules are scoped by the measurements of the old version, and what
+	// was converted can be changed by the field and tag rules
+	if c.Convert != nil {
+		c.Convert.Point(db, p)
+	}
+	if c.Fields != nil && !c.Fields.Point(db, p) {
+		return false
+	}
+	if c.Tags != nil {
+		c.Tags.Point(db, p)
+	}
+	if c.Rename != nil {
+		p.Measurement = c.Rename.Measurement(p.Measurement)
+	}
+	if c.Time != nil {
+		c.Time.Point(p)
+	}
+	if c.Sanitize != nil && !c.Sanitize.Point(p) {
+		return false
+	}
+	return true
+}
+
+// Precision returns the precision of the timestamps of the points, empty for
+// nanoseconds.
+func (c *Chain) Precision() string {
+	if c.Time == nil {
+		return ""
+	}
+	return c.Time.Precision()
+}
+
+// Field returns the measurement, name and type a field of a measurement of
+// db, as found in the fields of the old version, is written with, false when
+// it isn't written as a field. Tags moved to fields aren't known this way.
+func (c *Chain) Field(db, m, name, typ string) (string, string, string, bool) {
+	if c.Convert != nil && !c.Convert.Field(db, m, name) {
+		return "", "", "", false
+	}
+	if c.Fields != nil {
+		var ok bool
+		if name, typ, ok = c.Fields.Field(db, m, name, typ); !ok {
+			return "", "", "", false
+		}
+	}
+	if c.Rename != nil {
+		m = c.Rename.Name(m)
+	}
+	return m, name, typ, true
+}
+
+// Validate checks the transforms against the measurements of the old
+// version.
+func (c *Chain) Validate(measurements []string) error {
+	if c.Rename != nil {
+		return c.Rename.Validate(measurements)
+	}
+	return nil
+}
+
+// Report writes what the transforms changed.
+func (c *Chain) Report(w io.Writer) {
+	if c.Convert != nil {
+		c.Convert.Report(w)
+	}
+	if c.Fields != nil {
+		c.Fields.Report(w)
+	}
+	if c.Tags != nil {
+		c.Tags.Report(w)
+	}
+	if c.Rename != nil {
+		c.Rename.Report(w)
+	}
+	if c.Sanitize != nil {
+		c.Sanitize.Report(w)
+	}
+}
@@ -0,0 +1,65 @@
+package writer
+
+import (
+	"sync"
+	"time"
+)
+
+// Sizer adapts the points per write to the latency of the destination. The
+// size grows by a quarter while full writes finish under the target latency
+// and is halved when a write is slower than the target or fails.
+type Sizer struct {
+	mu     sync.Mutex
+	size   int
+	max    int
+	target time.Duration
+}
+
+func NewSizer(initial, max int, target time.Duration) *Sizer {
+	if initial > max {
+		initial = max
+	}
+	return &Sizer{size: initial, max: max, target: target}
+}
+
+// Size returns the points to send in the next write.
+func (s *Sizer) Size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// SetSize replaces the size, which keeps adapting from there.
+func (s *Sizer) SetSize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.size = clamp(size, s.max)
+}
+
+// Observe adjusts the size after a write of points that took latency.
+func (s *Sizer) Observe(points int, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case err != nil || latency > s.target:
+		s.size = clamp(s.size/2, s.max)
+	case points >= s.size:
+		// only full writes show the size can grow, the last write of a
+		// batch is usually smaller
+		grow := s.size / 4
+		if grow < 1 {
+			grow = 1
+		}
+		s.size = clamp(s.size+grow, s.max)
+	}
+}
+
+func clamp(size, max int) int {
+	if size < 1 {
+		return 1
+	}
+	if size > max {
+		return max
+	}
+	return size
+}
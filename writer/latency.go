@@ -0,0 +1,93 @@
+package writer
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencies keeps the latency of the last writes to report percentiles.
+type latencies struct {
+	mu     sync.Mutex
+	values []time.Duration
+	next   int
+}
+
+const keptlatencies = 1000
+
+func (l *latencies) add(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.values) < keptlatencies {
+		l.values = append(l.values, d)
+		return
+	}
+	l.values[l.next] = d
+	l.next = (l.next + 1) % keptlatencies
+}
+
+// percentiles returns the requested percentiles (0 to 100) of the kept
+// latencies, or zeros when there is none.
+func (l *latencies) percentiles(ps ...float64) []time.Duration {
+	l.mu.Lock()
+	sorted := make([]time.Duration, len(l.values))
+	copy(sorted, l.values)
+	l.mu.Unlock()
+
+	ret := make([]time.Duration, len(ps))
+	if len(sorted) == 0 {
+		return ret
+	}
+	sort.Sort(durations(sorted))
+	for i, p := range ps {
+		idx := int(p / 100 * float64(len(sorted)-1))
+		ret[i] = sorted[idx]
+	}
+	return ret
+}
+
+type durations []time.Duration
+
+func (a durations) Len() int           { return len(a) }
+func (a durations) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a durations) Less(i, j int) bool { return a[i] < a[j] }
+
+// LatencyBuckets are the upper bounds, in seconds, of the latency histogram.
+var LatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram counts the latency of every write in LatencyBuckets.
+type Histogram struct {
+	Counts []uint64 // cumulative, one for each bucket
+	Sum    float64  // seconds
+	Count  uint64
+}
+
+type histogram struct {
+	mu sync.Mutex
+	h  Histogram
+}
+
+func (h *histogram) add(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.h.Counts == nil {
+		h.h.Counts = make([]uint64, len(LatencyBuckets))
+	}
+	secs := d.Seconds()
+	for i, b := range LatencyBuckets {
+		if secs <= b {
+			h.h.Counts[i]++
+		}
+	}
+	h.h.Sum += secs
+	h.h.Count++
+}
+
+func (h *histogram) get() Histogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ret := h.h
+	ret.Counts = make([]uint64, len(LatencyBuckets))
+	copy(ret.Counts, h.h.Counts)
+	return ret
+}
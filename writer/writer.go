@@ -0,0 +1,205 @@
+package writer
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb/client"
+	"github.com/vladlopes/influxdb-migrate/database"
+	"github.com/vladlopes/influxdb-migrate/export"
+	"github.com/vladlopes/influxdb-migrate/progress"
+	"github.com/vladlopes/influxdb-migrate/ratelimit"
+)
+
+// Writer sends the batches decoded by the readers to the new version, or
+// encodes them to Export when there is no client.
+type Writer struct {
+	Client         *client.Client
+	Export         *export.Encoder
+	PointsPerWrite int
+	Workers        int
+	BetweenWrites  time.Duration
+	Limiter        *ratelimit.Limiter
+	Budget         *database.Budget
+	// Sizer, when set, replaces PointsPerWrite adapting it to the latency
+	// of the writes.
+	Sizer *Sizer
+	// Progress counts the points written and failed.
+	Progress *progress.Tracker
+	// Retries is how many times a failed write is sent again, waiting
+	// RetryWait before the first retry and twice as long before each
+	// following one.
+	Retries   int
+	RetryWait time.Duration
+	// Failures, when set, gets the batches that couldn't be written, to be
+	// replayed later.
+	Failures *export.Encoder
+
+	latencies latencies
+	histogram histogram
+
+	ctlmu  sync.Mutex
+	size   int
+	paused chan struct{}
+}
+
+// Run writes every batch received from cpoints using Workers goroutines and
+// returns once cpoints is closed and every batch was written.
+func (w *Writer) Run(cpoints <-chan client.BatchPoints) {
+	workers := w.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for bp := range cpoints {
+				w.writebatch(bp)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// CurrentPointsPerWrite returns the current maximum points per write.
+func (w *Writer) CurrentPointsPerWrite() int {
+	if w.Sizer != nil {
+		return w.Sizer.Size()
+	}
+	w.ctlmu.Lock()
+	defer w.ctlmu.Unlock()
+	if w.size > 0 {
+		return w.size
+	}
+	return w.PointsPerWrite
+}
+
+// SetPointsPerWrite changes the maximum points per write while running.
+// With a Sizer it is the size the Sizer adapts from.
+func (w *Writer) SetPointsPerWrite(n int) {
+	if w.Sizer != nil {
+		w.Sizer.SetSize(n)
+		return
+	}
+	w.ctlmu.Lock()
+	w.size = n
+	w.ctlmu.Unlock()
+}
+
+// Pause makes the writers wait before their next write until Resume. The
+// readers stop too once the batches waiting for a writer fill up.
+func (w *Writer) Pause() {
+	w.ctlmu.Lock()
+	defer w.ctlmu.Unlock()
+	if w.paused == nil {
+		w.paused = make(chan struct{})
+	}
+}
+
+// Resume lets the writers continue after a Pause.
+func (w *Writer) Resume() {
+	w.ctlmu.Lock()
+	defer w.ctlmu.Unlock()
+	if w.paused != nil {
+		close(w.paused)
+		w.paused = nil
+	}
+}
+
+// Paused tells whether the writers are paused.
+func (w *Writer) Paused() bool {
+	w.ctlmu.Lock()
+	defer w.ctlmu.Unlock()
+	return w.paused != nil
+}
+
+// wait blocks while the writers are paused.
+func (w *Writer) wait() {
+	w.ctlmu.Lock()
+	paused := w.paused
+	w.ctlmu.Unlock()
+	if paused != nil {
+		<-paused
+	}
+}
+
+// Latencies returns the 50th, 90th and 99th percentiles of the latency of
+// the last writes.
+func (w *Writer) Latencies() (p50, p90, p99 time.Duration) {
+	ps := w.latencies.percentiles(50, 90, 99)
+	return ps[0], ps[1], ps[2]
+}
+
+// LatencyHistogram returns the histogram of the latency of every write.
+func (w *Writer) LatencyHistogram() Histogram { return w.histogram.get() }
+
+// writebatch splits the batch in writes of at most PointsPerWrite points.
+func (w *Writer) writebatch(bp client.BatchPoints) {
+	size := database.BatchSize(bp)
+	points := bp.Points
+	for {
+		if len(points) < 1 {
+			break
+		}
+		w.wait()
+		max := w.CurrentPointsPerWrite()
+		if len(points) < max {
+			max = len(points)
+		}
+		bp.Points = points[:max]
+		bytes := database.BatchSize(bp)
+		w.Limiter.Wait(len(bp.Points), bytes)
+		if w.Client != nil {
+			if err := w.send(bp); err != nil {
+				log.Printf("Error writing %d points to rp %s on database %s: %v\n",
+					len(bp.Points), bp.RetentionPolicy, bp.Database, err)
+				w.Progress.Failed(bp.Database, bp.RetentionPolicy, len(bp.Points))
+				if w.Failures != nil {
+					n, _ := w.Failures.Encode(bp)
+					w.Progress.Saved(bp.Database, bp.RetentionPolicy, n)
+				}
+			} else {
+				w.Progress.Written(bp.Database, bp.RetentionPolicy, len(bp.Points), bytes)
+			}
+		} else {
+			n, err := w.Export.Encode(bp)
+			if err != nil {
+				log.Printf("%v\n", err)
+			}
+			w.Progress.Written(bp.Database, bp.RetentionPolicy, n, bytes)
+			if n < len(bp.Points) {
+				w.Progress.Failed(bp.Database, bp.RetentionPolicy, len(bp.Points)-n)
+			}
+		}
+		points = points[max:]
+		if w.BetweenWrites > 0 {
+			time.Sleep(w.BetweenWrites)
+		}
+	}
+	w.Budget.Release(size)
+}
+
+// send writes the points, sending them again up to Retries times while the
+// write fails.
+func (w *Writer) send(bp client.BatchPoints) error {
+	wait := w.RetryWait
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		_, err := w.Client.Write(bp)
+		latency := time.Since(start)
+		w.latencies.add(latency)
+		w.histogram.add(latency)
+		if w.Sizer != nil {
+			w.Sizer.Observe(len(bp.Points), latency, err)
+		}
+		if err == nil || attempt >= w.Retries {
+			return err
+		}
+		w.Progress.Retried()
+		time.Sleep(wait)
+		wait *= 2
+	}
+}